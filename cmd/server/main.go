@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,16 +10,25 @@ import (
 	"syscall"
 	"time"
 
+	redigo "github.com/gomodule/redigo/redis"
 	"github.com/jonboulle/clockwork"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/cshep4/news-api/internal/config"
 	"github.com/cshep4/news-api/internal/log"
 	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/news/archive"
 	"github.com/cshep4/news-api/internal/news/cache"
+	rediscache "github.com/cshep4/news-api/internal/news/cache/redis"
+	"github.com/cshep4/news-api/internal/news/cache/tiered"
+	"github.com/cshep4/news-api/internal/news/cluster"
 	httphandler "github.com/cshep4/news-api/internal/news/handler/http"
+	"github.com/cshep4/news-api/internal/news/refresher"
+	"github.com/cshep4/news-api/internal/news/search"
 	newsservice "github.com/cshep4/news-api/internal/news/service"
-	"github.com/cshep4/news-api/internal/provider/bbc"
-	"github.com/cshep4/news-api/internal/provider/sky"
+	"github.com/cshep4/news-api/internal/provider/jsonfeed"
+	"github.com/cshep4/news-api/internal/provider/resilience"
+	"github.com/cshep4/news-api/internal/provider/rss"
 	"github.com/cshep4/news-api/internal/secret"
 	httptransport "github.com/cshep4/news-api/internal/transport/http"
 )
@@ -36,35 +46,105 @@ func start(ctx context.Context) error {
 		return fmt.Errorf("failed to load secrets: %w", err)
 	}
 
-	cache, err := cache.New(clockwork.NewRealClock())
+	cfg, err := config.Load(s.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	clock := clockwork.NewRealClock()
+
+	cache, cacheStats, err := newCache(ctx, cfg.Cache, clock)
 	if err != nil {
 		return fmt.Errorf("failed to create cache: %w", err)
 	}
 
-	client := &http.Client{
-		Timeout: time.Second,
+	opts := make([]newsservice.Option, 0, len(cfg.Categories)+len(cfg.Providers)+5)
+	opts = append(opts, newsservice.WithConcurrency(cfg.Concurrency), newsservice.WithClock(clock))
+
+	var itemArchive archiveStore
+	if cfg.Archive.Path != "" {
+		a, err := archive.New(cfg.Archive.Path, clock)
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+		defer a.Close()
+
+		itemArchive = a
+		opts = append(opts, newsservice.WithArchive(a))
 	}
 
-	skyProvider, err := sky.New(s.SkyURL, client)
+	clusterer, err := cluster.New(
+		cluster.WithThreshold(cfg.Cluster.Threshold),
+		cluster.WithWindow(cfg.Cluster.Window),
+	)
 	if err != nil {
-		return fmt.Errorf("failed to create sky provider: %w", err)
+		return fmt.Errorf("failed to create clusterer: %w", err)
 	}
+	opts = append(opts, newsservice.WithClusterer(clusterer))
 
-	bbcProvider, err := bbc.New(s.BBCURL, client)
+	index, err := search.New(search.WithMaxItems(cfg.Search.MaxItems))
 	if err != nil {
-		return fmt.Errorf("failed to create bbc provider: %w", err)
+		return fmt.Errorf("failed to create search index: %w", err)
 	}
+	opts = append(opts, newsservice.WithIndex(index))
 
-	service, err := newsservice.New(cache,
-		newsservice.WithProvider(news.ProviderSky, skyProvider),
-		newsservice.WithProvider(news.ProviderBBC, bbcProvider),
-		newsservice.WithCategory(news.CategoryUK),
-		newsservice.WithCategory(news.CategoryTechnology),
-	)
+	for _, c := range cfg.Categories {
+		opts = append(opts, newsservice.WithCategory(c))
+	}
+
+	refresherOpts := []refresher.Option{
+		refresher.WithInterval(cfg.Cache.DefaultTTL),
+		refresher.WithJitter(cfg.Refresher.Jitter),
+		refresher.WithIndex(index),
+	}
+
+	circuits := make(map[news.Provider]circuitBreaker, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		provider, err := newProvider(p)
+		if err != nil {
+			return fmt.Errorf("failed to create %s provider: %w", p.Name, err)
+		}
+
+		resilientProvider, err := resilience.New(p.Name, provider, clock,
+			resilience.WithRetries(p.Retry.Retries),
+			resilience.WithBackoff(p.Retry.Backoff, p.Retry.MaxBackoff),
+			resilience.WithCircuitBreaker(p.CircuitBreaker.FailureThreshold, p.CircuitBreaker.OpenTimeout),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create %s resilient provider: %w", p.Name, err)
+		}
+		circuits[p.Name] = resilientProvider
+
+		var servedProvider newsservice.Provider = resilientProvider
+		if itemArchive != nil {
+			servedProvider, err = archive.NewRecorder(p.Name, resilientProvider, itemArchive)
+			if err != nil {
+				return fmt.Errorf("failed to create %s archive recorder: %w", p.Name, err)
+			}
+		}
+
+		opts = append(opts, newsservice.WithProvider(p.Name, servedProvider, p.Categories...))
+
+		categories := p.Categories
+		if len(categories) == 0 {
+			categories = cfg.Categories
+		}
+		refresherOpts = append(refresherOpts,
+			refresher.WithSource(p.Name, servedProvider, categories...),
+			refresher.WithRateLimit(p.Name, p.RateLimit),
+		)
+	}
+
+	service, err := newsservice.New(cache, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to create news service: %w", err)
 	}
 
+	feedRefresher, err := refresher.New(clock, cache, refresherOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to create refresher: %w", err)
+	}
+
 	handler, err := httphandler.New(service)
 	if err != nil {
 		return fmt.Errorf("failed to create http handler: %w", err)
@@ -80,6 +160,9 @@ func start(ctx context.Context) error {
 		httptransport.WithRegisterer(httptransport.Health()),
 		httptransport.WithRegisterer(httptransport.Live()),
 		httptransport.WithRegisterer(httptransport.Version(version)),
+		httptransport.WithRegisterer(httptransport.NewRegisterer("/_circuits", circuitStates(circuits), http.MethodGet)),
+		httptransport.WithRegisterer(httptransport.NewRegisterer("/_refresher", refresherStatus(feedRefresher), http.MethodGet)),
+		httptransport.WithRegisterer(httptransport.NewRegisterer("/_cache", stats(cacheStats), http.MethodGet)),
 	)
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -93,8 +176,14 @@ func start(ctx context.Context) error {
 		return healthServer.Start(ctx)
 	})
 
+	if cfg.Refresher.Enabled {
+		g.Go(func() error {
+			return feedRefresher.Start(ctx)
+		})
+	}
+
 	g.Go(func() error {
-		c := make(chan os.Signal)
+		c := make(chan os.Signal, 1)
 		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 		select {
@@ -111,6 +200,183 @@ func start(ctx context.Context) error {
 	return g.Wait()
 }
 
+type archiveStore interface {
+	newsservice.Archive
+	archive.Archive
+}
+
+type circuitBreaker interface {
+	State() resilience.State
+}
+
+// circuitStates reports the circuit breaker state of each provider.
+func circuitStates(circuits map[news.Provider]circuitBreaker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		states := make(map[news.Provider]resilience.State, len(circuits))
+		for p, c := range circuits {
+			states[p] = c.State()
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(states); err != nil {
+			log.Error(r.Context(), "encode_response_error", log.ErrorParam(err))
+		}
+	}
+}
+
+type statusReporter interface {
+	Status() []refresher.Status
+}
+
+// refresherStatus reports the outcome of the last background refresh of each feed.
+func refresherStatus(s statusReporter) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.Status()); err != nil {
+			log.Error(r.Context(), "encode_response_error", log.ErrorParam(err))
+		}
+	}
+}
+
+// stats reports the stats returned by f.
+func stats(f func() interface{}) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(f()); err != nil {
+			log.Error(r.Context(), "encode_response_error", log.ErrorParam(err))
+		}
+	}
+}
+
+// newCache creates the cache of the configured type, and a function returning its stats.
+func newCache(ctx context.Context, c config.Cache, clock clockwork.Clock) (newsservice.Cache, func() interface{}, error) {
+	newMemoryCache := func() (memoryCache, error) {
+		return cache.New(clock,
+			cache.WithDefaultTTL(c.DefaultTTL),
+			cache.WithMaxStale(c.MaxStale),
+			cache.WithMaxEntries(c.MaxEntries),
+			cache.WithMaxBytes(c.MaxBytes),
+		)
+	}
+
+	newRedisCache := func() (redisCache, error) {
+		pool := &redigo.Pool{
+			MaxIdle:     10,
+			IdleTimeout: 5 * time.Minute,
+			Dial: func() (redigo.Conn, error) {
+				return redigo.DialURL(c.Redis.URL,
+					redigo.DialConnectTimeout(c.Redis.Timeout),
+					redigo.DialReadTimeout(c.Redis.Timeout),
+					redigo.DialWriteTimeout(c.Redis.Timeout),
+				)
+			},
+		}
+
+		opts := []rediscache.Option{
+			rediscache.WithDefaultTTL(c.DefaultTTL),
+			rediscache.WithMaxStale(c.MaxStale),
+			rediscache.WithLogContext(ctx),
+		}
+		if c.Redis.Prefix != "" {
+			opts = append(opts, rediscache.WithPrefix(c.Redis.Prefix))
+		}
+
+		return rediscache.New(pool, clock, opts...)
+	}
+
+	switch c.Type {
+	case config.CacheTypeMemory:
+		l1, err := newMemoryCache()
+		if err != nil {
+			return nil, nil, err
+		}
+		return l1, func() interface{} { return l1.Stats() }, nil
+	case config.CacheTypeRedis:
+		l2, err := newRedisCache()
+		if err != nil {
+			return nil, nil, err
+		}
+		return l2, func() interface{} { return l2.Stats() }, nil
+	case config.CacheTypeTiered:
+		l1, err := newMemoryCache()
+		if err != nil {
+			return nil, nil, err
+		}
+		l2, err := newRedisCache()
+		if err != nil {
+			return nil, nil, err
+		}
+		t, err := tiered.New(l1, l2)
+		if err != nil {
+			return nil, nil, err
+		}
+		return t, func() interface{} {
+			return map[string]interface{}{"l1": l1.Stats(), "l2": l2.Stats()}
+		}, nil
+	}
+
+	return nil, nil, fmt.Errorf("unsupported cache type: %s", c.Type)
+}
+
+type memoryCache interface {
+	tiered.L1
+	Stats() cache.Stats
+}
+
+type redisCache interface {
+	tiered.L2
+	Get(provider news.Provider, category news.Category) (*news.Feed, bool)
+	GetStale(provider news.Provider, category news.Category) (*news.Feed, bool)
+	Stats() rediscache.Stats
+}
+
+func newProvider(p config.Provider) (newsservice.Provider, error) {
+	client := &http.Client{
+		Timeout: p.Timeout,
+	}
+
+	switch p.Type {
+	case config.ProviderTypeRSS:
+		var opts []rss.Option
+		if p.URLTemplate != "" {
+			opts = append(opts, rss.WithURLTemplate(p.URLTemplate))
+		}
+		if len(p.Thumbnail) > 0 {
+			thumbnail := make([]rss.Thumbnail, 0, len(p.Thumbnail))
+			for _, t := range p.Thumbnail {
+				thumbnail = append(thumbnail, rss.Thumbnail(t))
+			}
+			opts = append(opts, rss.WithThumbnail(thumbnail...))
+		}
+		if p.ThumbnailWidth > 0 {
+			opts = append(opts, rss.WithThumbnailWidth(p.ThumbnailWidth))
+		}
+		if p.MaxBodySize > 0 {
+			opts = append(opts, rss.WithMaxBodySize(p.MaxBodySize))
+		}
+		if p.MaxItems > 0 {
+			opts = append(opts, rss.WithMaxItems(p.MaxItems))
+		}
+
+		return rss.New(p.Name, p.BaseURL, client, opts...)
+	case config.ProviderTypeJSONFeed:
+		var opts []jsonfeed.Option
+		if p.URLTemplate != "" {
+			opts = append(opts, jsonfeed.WithURLTemplate(p.URLTemplate))
+		}
+		if p.MaxBodySize > 0 {
+			opts = append(opts, jsonfeed.WithMaxBodySize(p.MaxBodySize))
+		}
+		if p.MaxItems > 0 {
+			opts = append(opts, jsonfeed.WithMaxItems(p.MaxItems))
+		}
+
+		return jsonfeed.New(p.Name, p.BaseURL, client, opts...)
+	}
+
+	return nil, fmt.Errorf("unsupported provider type: %s", p.Type)
+}
+
 func main() {
 	ctx := log.WithServiceName(context.Background(), log.New(logLevel), serviceName)
 	if err := start(ctx); err != nil {
@@ -3,14 +3,19 @@ module github.com/cshep4/news-api
 go 1.15
 
 require (
+	github.com/alicebob/miniredis/v2 v2.14.3
 	github.com/golang/mock v1.4.4
+	github.com/gomodule/redigo v1.8.5
 	github.com/gorilla/mux v1.8.0
 	github.com/jonboulle/clockwork v0.2.2
 	github.com/palantir/witchcraft-go-logging v1.9.0
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/rs/cors v1.7.0
 	github.com/stretchr/testify v1.7.0
+	go.etcd.io/bbolt v1.3.6
 	golang.org/x/sync v0.0.0-20201207232520-09787c993a3a
+	golang.org/x/sys v0.7.0 // indirect
+	golang.org/x/text v0.3.6
 	google.golang.org/grpc v1.34.0 // indirect
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,413 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/cshep4/news-api/internal/news"
+)
+
+const (
+	ProviderTypeRSS      ProviderType = "rss"
+	ProviderTypeJSONFeed ProviderType = "jsonfeed"
+
+	CacheTypeMemory CacheType = "memory"
+	CacheTypeRedis  CacheType = "redis"
+	CacheTypeTiered CacheType = "tiered"
+
+	defaultConcurrency      = 10
+	defaultCacheTTL         = time.Minute
+	defaultMaxStale         = time.Hour
+	defaultMaxEntries       = 1000
+	defaultMaxBytes         = 64 << 20
+	defaultRedisTimeout     = time.Second
+	defaultJitter           = 0.1
+	defaultClusterThreshold = 0.4
+	defaultClusterWindow    = 48 * time.Hour
+	defaultSearchMaxItems   = 10000
+	defaultTimeout          = time.Second
+	defaultBackoff          = 100 * time.Millisecond
+	defaultMaxBackoff       = time.Second
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+)
+
+// reservedCategories are paths served by the API that a category can't be named, as
+// its feed would be unreachable.
+var reservedCategories = map[news.Category]struct{}{
+	"search": {},
+}
+
+// nameSeparator separates the provider and category in the keys of cached feeds, so
+// names can't contain it.
+const nameSeparator = ":"
+
+type (
+	ProviderType string
+
+	// CacheType is where feeds are cached: in memory, in Redis, or in memory in front of Redis.
+	CacheType string
+
+	// Config describes the providers and categories served by the news service.
+	Config struct {
+		Categories []news.Category `yaml:"categories"`
+		Providers  []Provider      `yaml:"providers"`
+		// Concurrency is the max number of feeds retrieved concurrently for a request.
+		Concurrency int       `yaml:"concurrency"`
+		Cache       Cache     `yaml:"cache"`
+		Refresher   Refresher `yaml:"refresher"`
+		Archive     Archive   `yaml:"archive"`
+		Cluster     Cluster   `yaml:"cluster"`
+		Search      Search    `yaml:"search"`
+	}
+
+	Archive struct {
+		// Path is the file items are archived in. Items aren't archived if it's empty.
+		Path string `yaml:"path"`
+	}
+
+	Cache struct {
+		Type CacheType `yaml:"type"`
+		// DefaultTTL is how long feeds that don't specify a TTL are fresh for.
+		DefaultTTL time.Duration `yaml:"defaultTtl"`
+		// MaxStale is how long a feed is served for after its TTL has passed.
+		MaxStale time.Duration `yaml:"maxStale"`
+		// MaxEntries is the max number of feeds that are cached.
+		MaxEntries int `yaml:"maxEntries"`
+		// MaxBytes is the max total size of the cached feeds.
+		MaxBytes int64 `yaml:"maxBytes"`
+		Redis    Redis `yaml:"redis"`
+	}
+
+	Redis struct {
+		URL     string        `yaml:"url"`
+		Prefix  string        `yaml:"prefix"`
+		Timeout time.Duration `yaml:"timeout"`
+	}
+
+	Refresher struct {
+		// Enabled starts a background refresher that keeps the cache warm.
+		Enabled bool `yaml:"enabled"`
+		// Jitter is the max fraction of a feed's TTL by which its refresh is brought forward.
+		Jitter float64 `yaml:"jitter"`
+	}
+
+	Cluster struct {
+		// Threshold is the min similarity, between 0 and 1, of the titles and descriptions
+		// of items from different providers for them to be about the same story.
+		Threshold float64 `yaml:"threshold"`
+		// Window is the max time between items that are about the same story.
+		Window time.Duration `yaml:"window"`
+	}
+
+	Search struct {
+		// MaxItems is the max number of items that can be searched, after which the
+		// oldest are dropped.
+		MaxItems int `yaml:"maxItems"`
+	}
+
+	Provider struct {
+		Name           news.Provider   `yaml:"name"`
+		Type           ProviderType    `yaml:"type"`
+		BaseURL        string          `yaml:"baseUrl"`
+		URLTemplate    string          `yaml:"urlTemplate"`
+		Timeout        time.Duration   `yaml:"timeout"`
+		Categories     []news.Category `yaml:"categories"`
+		Thumbnail      []string        `yaml:"thumbnail"`
+		ThumbnailWidth int             `yaml:"thumbnailWidth"`
+		MaxBodySize    int64           `yaml:"maxBodySize"`
+		MaxItems       int             `yaml:"maxItems"`
+		// RateLimit is the min time between background refreshes of the provider's feeds.
+		RateLimit time.Duration `yaml:"rateLimit"`
+
+		Retry          Retry          `yaml:"retry"`
+		CircuitBreaker CircuitBreaker `yaml:"circuitBreaker"`
+	}
+
+	Retry struct {
+		Retries    int           `yaml:"retries"`
+		Backoff    time.Duration `yaml:"backoff"`
+		MaxBackoff time.Duration `yaml:"maxBackoff"`
+	}
+
+	CircuitBreaker struct {
+		FailureThreshold int           `yaml:"failureThreshold"`
+		OpenTimeout      time.Duration `yaml:"openTimeout"`
+	}
+)
+
+// Load reads and validates the YAML or JSON config file at path. References to
+// environment variables, e.g. ${BBC_URL}, are expanded before parsing.
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	expanded, err := expandEnv(string(b))
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := yaml.UnmarshalStrict([]byte(expanded), &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return &c, nil
+}
+
+// Validate checks the config and applies defaults to optional fields.
+func (c *Config) Validate() error {
+	if len(c.Categories) == 0 {
+		return news.InvalidParameterError{Parameter: "categories"}
+	}
+
+	switch {
+	case c.Concurrency < 0:
+		return news.InvalidParameterError{Parameter: "concurrency"}
+	case c.Concurrency == 0:
+		c.Concurrency = defaultConcurrency
+	}
+
+	if err := c.Cache.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Refresher.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Cluster.validate(); err != nil {
+		return err
+	}
+
+	switch {
+	case c.Search.MaxItems < 0:
+		return news.InvalidParameterError{Parameter: "search.maxItems"}
+	case c.Search.MaxItems == 0:
+		c.Search.MaxItems = defaultSearchMaxItems
+	}
+
+	categories := make(map[news.Category]struct{})
+	for i, category := range c.Categories {
+		_, reserved := reservedCategories[category]
+		separated := strings.Contains(string(category), nameSeparator)
+		if _, ok := categories[category]; ok || reserved || separated || category == "" {
+			return fmt.Errorf("categories[%d]: %w", i, news.InvalidParameterError{Parameter: "category"})
+		}
+		categories[category] = struct{}{}
+	}
+
+	providers := make(map[news.Provider]struct{})
+	for i := range c.Providers {
+		p := &c.Providers[i]
+
+		separated := strings.Contains(string(p.Name), nameSeparator)
+		if _, ok := providers[p.Name]; ok || separated || p.Name == news.ProviderAll {
+			return fmt.Errorf("providers[%d]: %w", i, news.InvalidParameterError{Parameter: "name"})
+		}
+		providers[p.Name] = struct{}{}
+
+		if err := p.validate(categories); err != nil {
+			return fmt.Errorf("providers[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) validate(categories map[news.Category]struct{}) error {
+	switch p.Type {
+	case ProviderTypeRSS, ProviderTypeJSONFeed:
+	default:
+		return news.InvalidParameterError{Parameter: "type"}
+	}
+
+	if u, err := url.Parse(p.BaseURL); err != nil || !u.IsAbs() {
+		return news.InvalidParameterError{Parameter: "baseUrl"}
+	}
+
+	if p.URLTemplate != "" && !strings.Contains(p.URLTemplate, "{category}") {
+		return news.InvalidParameterError{Parameter: "urlTemplate"}
+	}
+
+	switch {
+	case p.Timeout < 0:
+		return news.InvalidParameterError{Parameter: "timeout"}
+	case p.Timeout == 0:
+		p.Timeout = defaultTimeout
+	}
+
+	switch {
+	case p.ThumbnailWidth < 0:
+		return news.InvalidParameterError{Parameter: "thumbnailWidth"}
+	case p.MaxBodySize < 0:
+		return news.InvalidParameterError{Parameter: "maxBodySize"}
+	case p.MaxItems < 0:
+		return news.InvalidParameterError{Parameter: "maxItems"}
+	case p.RateLimit < 0:
+		return news.InvalidParameterError{Parameter: "rateLimit"}
+	}
+
+	for _, category := range p.Categories {
+		if _, ok := categories[category]; !ok {
+			return news.InvalidParameterError{Parameter: "categories"}
+		}
+	}
+
+	if err := p.Retry.validate(); err != nil {
+		return err
+	}
+
+	return p.CircuitBreaker.validate()
+}
+
+func (c *Cache) validate() error {
+	switch c.Type {
+	case "":
+		c.Type = CacheTypeMemory
+	case CacheTypeMemory:
+	case CacheTypeRedis, CacheTypeTiered:
+		if err := c.Redis.validate(); err != nil {
+			return err
+		}
+	default:
+		return news.InvalidParameterError{Parameter: "cache.type"}
+	}
+
+	if c.DefaultTTL == 0 {
+		c.DefaultTTL = defaultCacheTTL
+	}
+	if c.MaxStale == 0 {
+		c.MaxStale = defaultMaxStale
+	}
+	if c.MaxEntries == 0 {
+		c.MaxEntries = defaultMaxEntries
+	}
+	if c.MaxBytes == 0 {
+		c.MaxBytes = defaultMaxBytes
+	}
+
+	switch {
+	case c.DefaultTTL < 0:
+		return news.InvalidParameterError{Parameter: "cache.defaultTtl"}
+	case c.MaxStale < 0:
+		return news.InvalidParameterError{Parameter: "cache.maxStale"}
+	case c.MaxEntries < 0:
+		return news.InvalidParameterError{Parameter: "cache.maxEntries"}
+	case c.MaxBytes < 0:
+		return news.InvalidParameterError{Parameter: "cache.maxBytes"}
+	}
+
+	return nil
+}
+
+func (r *Redis) validate() error {
+	if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+		return news.InvalidParameterError{Parameter: "cache.redis.url"}
+	}
+
+	switch {
+	case r.Timeout < 0:
+		return news.InvalidParameterError{Parameter: "cache.redis.timeout"}
+	case r.Timeout == 0:
+		r.Timeout = defaultRedisTimeout
+	}
+
+	return nil
+}
+
+func (r *Refresher) validate() error {
+	switch {
+	case r.Jitter < 0 || r.Jitter >= 1:
+		return news.InvalidParameterError{Parameter: "refresher.jitter"}
+	case r.Jitter == 0:
+		r.Jitter = defaultJitter
+	}
+
+	return nil
+}
+
+func (c *Cluster) validate() error {
+	switch {
+	case c.Threshold < 0 || c.Threshold > 1:
+		return news.InvalidParameterError{Parameter: "cluster.threshold"}
+	case c.Threshold == 0:
+		c.Threshold = defaultClusterThreshold
+	}
+
+	switch {
+	case c.Window < 0:
+		return news.InvalidParameterError{Parameter: "cluster.window"}
+	case c.Window == 0:
+		c.Window = defaultClusterWindow
+	}
+
+	return nil
+}
+
+func (r *Retry) validate() error {
+	if r.Backoff == 0 {
+		r.Backoff = defaultBackoff
+	}
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = defaultMaxBackoff
+	}
+
+	switch {
+	case r.Retries < 0:
+		return news.InvalidParameterError{Parameter: "retry.retries"}
+	case r.Backoff < 0:
+		return news.InvalidParameterError{Parameter: "retry.backoff"}
+	case r.MaxBackoff < r.Backoff:
+		return news.InvalidParameterError{Parameter: "retry.maxBackoff"}
+	}
+
+	return nil
+}
+
+func (c *CircuitBreaker) validate() error {
+	if c.FailureThreshold == 0 {
+		c.FailureThreshold = defaultFailureThreshold
+	}
+	if c.OpenTimeout == 0 {
+		c.OpenTimeout = defaultOpenTimeout
+	}
+
+	switch {
+	case c.FailureThreshold < 0:
+		return news.InvalidParameterError{Parameter: "circuitBreaker.failureThreshold"}
+	case c.OpenTimeout < 0:
+		return news.InvalidParameterError{Parameter: "circuitBreaker.openTimeout"}
+	}
+
+	return nil
+}
+
+func expandEnv(s string) (string, error) {
+	var missing []string
+	expanded := os.Expand(s, func(k string) string {
+		v, ok := os.LookupEnv(k)
+		if !ok {
+			missing = append(missing, k)
+		}
+		return v
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing env variable: %s", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,388 @@
+package config_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/config"
+	"github.com/cshep4/news-api/internal/news"
+)
+
+var (
+	defaultRetry = config.Retry{
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: time.Second,
+	}
+	defaultCircuitBreaker = config.CircuitBreaker{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+	defaultCache = config.Cache{
+		Type:       config.CacheTypeMemory,
+		DefaultTTL: time.Minute,
+		MaxStale:   time.Hour,
+		MaxEntries: 1000,
+		MaxBytes:   64 << 20,
+	}
+	defaultRefresher = config.Refresher{
+		Jitter: 0.1,
+	}
+	defaultCluster = config.Cluster{
+		Threshold: 0.4,
+		Window:    48 * time.Hour,
+	}
+	defaultSearch = config.Search{
+		MaxItems: 10000,
+	}
+)
+
+func writeConfig(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func TestLoad_Error(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		content                string
+		expectedErr            string
+		expectedErrorParameter string
+	}{
+		{
+			name:        "invalid yaml",
+			content:     "categories: [uk",
+			expectedErr: "failed to unmarshal config",
+		},
+		{
+			name:        "unknown field",
+			content:     "categories: [uk]\nunknown: true",
+			expectedErr: "failed to unmarshal config",
+		},
+		{
+			name:        "missing env variable",
+			content:     "categories: [uk]\nproviders:\n  - name: bbc\n    type: rss\n    baseUrl: ${MISSING_URL}",
+			expectedErr: "missing env variable: MISSING_URL",
+		},
+		{
+			name:                   "no categories",
+			content:                "providers: []",
+			expectedErrorParameter: "categories",
+		},
+		{
+			name:                   "negative concurrency",
+			content:                "categories: [uk]\nconcurrency: -1",
+			expectedErrorParameter: "concurrency",
+		},
+		{
+			name:                   "negative max stale",
+			content:                "categories: [uk]\ncache: {maxStale: -1s}",
+			expectedErrorParameter: "cache.maxStale",
+		},
+		{
+			name:                   "invalid cache type",
+			content:                "categories: [uk]\ncache: {type: disk}",
+			expectedErrorParameter: "cache.type",
+		},
+		{
+			name:                   "invalid redis url",
+			content:                "categories: [uk]\ncache: {type: redis, redis: {url: 'http://localhost:6379'}}",
+			expectedErrorParameter: "cache.redis.url",
+		},
+		{
+			name:                   "negative max entries",
+			content:                "categories: [uk]\ncache: {maxEntries: -1}",
+			expectedErrorParameter: "cache.maxEntries",
+		},
+		{
+			name:                   "invalid refresher jitter",
+			content:                "categories: [uk]\nrefresher: {jitter: 1}",
+			expectedErrorParameter: "refresher.jitter",
+		},
+		{
+			name:                   "invalid cluster threshold",
+			content:                "categories: [uk]\ncluster: {threshold: 1.5}",
+			expectedErrorParameter: "cluster.threshold",
+		},
+		{
+			name:                   "negative cluster window",
+			content:                "categories: [uk]\ncluster: {window: -1h}",
+			expectedErrorParameter: "cluster.window",
+		},
+		{
+			name:                   "negative search max items",
+			content:                "categories: [uk]\nsearch: {maxItems: -1}",
+			expectedErrorParameter: "search.maxItems",
+		},
+		{
+			name:                   "reserved category",
+			content:                "categories: [uk, search]",
+			expectedErrorParameter: "category",
+		},
+		{
+			name:                   "duplicate category",
+			content:                "categories: [uk, uk]",
+			expectedErrorParameter: "category",
+		},
+		{
+			name:                   "category with separator",
+			content:                "categories: ['uk:politics']",
+			expectedErrorParameter: "category",
+		},
+		{
+			name:                   "missing provider name",
+			content:                "categories: [uk]\nproviders:\n  - type: rss\n    baseUrl: http://test.com",
+			expectedErrorParameter: "name",
+		},
+		{
+			name:                   "duplicate provider name",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com'}\n  - {name: bbc, type: rss, baseUrl: 'http://test.com'}",
+			expectedErrorParameter: "name",
+		},
+		{
+			name:                   "provider name with separator",
+			content:                "categories: [uk]\nproviders:\n  - {name: 'bbc:news', type: rss, baseUrl: 'http://test.com'}",
+			expectedErrorParameter: "name",
+		},
+		{
+			name:                   "invalid provider type",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: html, baseUrl: 'http://test.com'}",
+			expectedErrorParameter: "type",
+		},
+		{
+			name:                   "invalid base url",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: test}",
+			expectedErrorParameter: "baseUrl",
+		},
+		{
+			name:                   "invalid url template",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com', urlTemplate: '{base}/rss.xml'}",
+			expectedErrorParameter: "urlTemplate",
+		},
+		{
+			name:                   "negative timeout",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com', timeout: -1s}",
+			expectedErrorParameter: "timeout",
+		},
+		{
+			name:                   "negative retries",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com', retry: {retries: -1}}",
+			expectedErrorParameter: "retry.retries",
+		},
+		{
+			name:                   "max backoff less than backoff",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com', retry: {backoff: 2s, maxBackoff: 1s}}",
+			expectedErrorParameter: "retry.maxBackoff",
+		},
+		{
+			name:                   "negative failure threshold",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com', circuitBreaker: {failureThreshold: -1}}",
+			expectedErrorParameter: "circuitBreaker.failureThreshold",
+		},
+		{
+			name:                   "negative thumbnail width",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com', thumbnailWidth: -1}",
+			expectedErrorParameter: "thumbnailWidth",
+		},
+		{
+			name:                   "negative max items",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com', maxItems: -1}",
+			expectedErrorParameter: "maxItems",
+		},
+		{
+			name:                   "negative rate limit",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com', rateLimit: -1s}",
+			expectedErrorParameter: "rateLimit",
+		},
+		{
+			name:                   "unknown provider category",
+			content:                "categories: [uk]\nproviders:\n  - {name: bbc, type: rss, baseUrl: 'http://test.com', categories: [sport]}",
+			expectedErrorParameter: "categories",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := config.Load(writeConfig(t, tc.content))
+			require.Error(t, err)
+			require.Nil(t, c)
+
+			if tc.expectedErrorParameter == "" {
+				assert.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+
+			var ipe news.InvalidParameterError
+			require.True(t, errors.As(err, &ipe))
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestLoad_Success(t *testing.T) {
+	require.NoError(t, os.Setenv("TEST_BBC_URL", "http://feeds.bbci.co.uk/news"))
+	defer os.Unsetenv("TEST_BBC_URL")
+
+	testCases := []struct {
+		name           string
+		content        string
+		expectedConfig *config.Config
+	}{
+		{
+			name: "yaml config",
+			content: `
+categories:
+  - uk
+  - technology
+concurrency: 4
+cache:
+  type: tiered
+  defaultTtl: 5m
+  maxStale: 30m
+  maxEntries: 100
+  maxBytes: 1048576
+  redis:
+    url: redis://localhost:6379/0
+    prefix: "news:"
+refresher:
+  enabled: true
+  jitter: 0.2
+archive:
+  path: /data/archive.db
+cluster:
+  threshold: 0.5
+  window: 24h
+search:
+  maxItems: 500
+providers:
+  - name: bbc
+    type: rss
+    baseUrl: ${TEST_BBC_URL}
+    urlTemplate: "{base}/{category}/rss.xml"
+    timeout: 2s
+    categories: [uk]
+    thumbnail: [media_thumbnail, channel_image]
+    thumbnailWidth: 240
+    maxBodySize: 1048576
+    maxItems: 50
+    rateLimit: 1s
+    retry:
+      retries: 2
+      backoff: 50ms
+      maxBackoff: 500ms
+    circuitBreaker:
+      failureThreshold: 3
+      openTimeout: 1m
+  - name: blog
+    type: jsonfeed
+    baseUrl: http://blog.com
+`,
+			expectedConfig: &config.Config{
+				Categories:  []news.Category{news.CategoryUK, news.CategoryTechnology},
+				Concurrency: 4,
+				Cache: config.Cache{
+					Type:       config.CacheTypeTiered,
+					DefaultTTL: 5 * time.Minute,
+					MaxStale:   30 * time.Minute,
+					MaxEntries: 100,
+					MaxBytes:   1 << 20,
+					Redis: config.Redis{
+						URL:     "redis://localhost:6379/0",
+						Prefix:  "news:",
+						Timeout: time.Second,
+					},
+				},
+				Refresher: config.Refresher{
+					Enabled: true,
+					Jitter:  0.2,
+				},
+				Archive: config.Archive{
+					Path: "/data/archive.db",
+				},
+				Cluster: config.Cluster{
+					Threshold: 0.5,
+					Window:    24 * time.Hour,
+				},
+				Search: config.Search{
+					MaxItems: 500,
+				},
+				Providers: []config.Provider{
+					{
+						Name:           news.ProviderBBC,
+						Type:           config.ProviderTypeRSS,
+						BaseURL:        "http://feeds.bbci.co.uk/news",
+						URLTemplate:    "{base}/{category}/rss.xml",
+						Timeout:        2 * time.Second,
+						Categories:     []news.Category{news.CategoryUK},
+						Thumbnail:      []string{"media_thumbnail", "channel_image"},
+						ThumbnailWidth: 240,
+						MaxBodySize:    1 << 20,
+						MaxItems:       50,
+						RateLimit:      time.Second,
+						Retry: config.Retry{
+							Retries:    2,
+							Backoff:    50 * time.Millisecond,
+							MaxBackoff: 500 * time.Millisecond,
+						},
+						CircuitBreaker: config.CircuitBreaker{
+							FailureThreshold: 3,
+							OpenTimeout:      time.Minute,
+						},
+					},
+					{
+						Name:           "blog",
+						Type:           config.ProviderTypeJSONFeed,
+						BaseURL:        "http://blog.com",
+						Timeout:        time.Second,
+						Retry:          defaultRetry,
+						CircuitBreaker: defaultCircuitBreaker,
+					},
+				},
+			},
+		},
+		{
+			name:    "json config",
+			content: `{"categories": ["uk"], "providers": [{"name": "sky", "type": "rss", "baseUrl": "http://sky.com", "timeout": "500ms"}]}`,
+			expectedConfig: &config.Config{
+				Categories:  []news.Category{news.CategoryUK},
+				Concurrency: 10,
+				Cache:       defaultCache,
+				Refresher:   defaultRefresher,
+				Cluster:     defaultCluster,
+				Search:      defaultSearch,
+				Providers: []config.Provider{
+					{
+						Name:           news.ProviderSky,
+						Type:           config.ProviderTypeRSS,
+						BaseURL:        "http://sky.com",
+						Timeout:        500 * time.Millisecond,
+						Retry:          defaultRetry,
+						CircuitBreaker: defaultCircuitBreaker,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := config.Load(writeConfig(t, tc.content))
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedConfig, c)
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/cshep4/news-api/internal/news"
+)
+
+const openTimeout = time.Second
+
+var (
+	// bucketItems holds the record of each item, keyed by the item ID.
+	bucketItems = []byte("items")
+	// bucketIndex holds a bucket for each provider and category, with keys made of
+	// the date of an item and its ID so that the items are ordered by date.
+	bucketIndex = []byte("index")
+)
+
+type (
+	archive struct {
+		db    *bolt.DB
+		clock clockwork.Clock
+	}
+
+	// Query selects the archived items of a feed, newest first and then by ID, as they
+	// are in a feed. Only the items after Cursor, published at or after Since and at or
+	// before Until are selected when they're set, up to Limit items, or every item if
+	// Limit is 0.
+	Query struct {
+		Cursor *news.Cursor
+		Since  time.Time
+		Until  time.Time
+		Limit  int
+	}
+
+	// record is an archived item along with the categories it has appeared in, and
+	// when it was first and last seen in a feed.
+	record struct {
+		Item       news.Item       `json:"item"`
+		Categories []news.Category `json:"categories"`
+		FirstSeen  time.Time       `json:"firstSeen"`
+		LastSeen   time.Time       `json:"lastSeen"`
+	}
+)
+
+// New opens the archive stored in the file at path, creating it if it doesn't exist.
+// The archive keeps every item recorded from the feeds, so that items can still be
+// served after they've dropped out of the live feeds.
+func New(path string, clock clockwork.Clock) (*archive, error) {
+	switch {
+	case path == "":
+		return nil, news.InvalidParameterError{Parameter: "path"}
+	case clock == nil:
+		return nil, news.InvalidParameterError{Parameter: "clock"}
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{bucketItems, bucketIndex} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create buckets: %w", err)
+	}
+
+	return &archive{
+		db:    db,
+		clock: clock,
+	}, nil
+}
+
+// Close closes the archive file.
+func (a *archive) Close() error {
+	return a.db.Close()
+}
+
+// Record archives items, updating those that have been seen before.
+func (a *archive) Record(items []news.Item) error {
+	now := a.clock.Now()
+
+	return a.db.Update(func(tx *bolt.Tx) error {
+		records := tx.Bucket(bucketItems)
+		index := tx.Bucket(bucketIndex)
+
+		for _, i := range items {
+			id := []byte(i.ID())
+
+			r := record{
+				Item:       i,
+				Categories: []news.Category{i.Category},
+				FirstSeen:  now,
+				LastSeen:   now,
+			}
+
+			if b := records.Get(id); b != nil {
+				var prev record
+				if err := json.Unmarshal(b, &prev); err != nil {
+					return fmt.Errorf("failed to unmarshal record: %w", err)
+				}
+
+				r.FirstSeen = prev.FirstSeen
+				r.Categories = addCategory(prev.Categories, i.Category)
+
+				// the item is indexed by date, so a changed date moves it in the index
+				if !prev.Item.DateTime.Equal(i.DateTime) {
+					for _, c := range prev.Categories {
+						if b := index.Bucket(sourceKey(prev.Item.Provider, c)); b != nil {
+							if err := b.Delete(indexKey(prev.Item.DateTime, id)); err != nil {
+								return fmt.Errorf("failed to delete index: %w", err)
+							}
+						}
+					}
+				}
+			}
+
+			b, err := json.Marshal(r)
+			if err != nil {
+				return fmt.Errorf("failed to marshal record: %w", err)
+			}
+			if err := records.Put(id, b); err != nil {
+				return fmt.Errorf("failed to put record: %w", err)
+			}
+
+			for _, c := range r.Categories {
+				source, err := index.CreateBucketIfNotExists(sourceKey(i.Provider, c))
+				if err != nil {
+					return fmt.Errorf("failed to create index: %w", err)
+				}
+				if err := source.Put(indexKey(i.DateTime, id), nil); err != nil {
+					return fmt.Errorf("failed to put index: %w", err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// Items returns the archived items of the provider's category feed selected by
+// query, newest first. The index is ordered by date, so the items are read starting
+// from the earlier of the cursor and Until, and stop at Since, rather than reading
+// from the newest item.
+func (a *archive) Items(provider news.Provider, category news.Category, query Query) ([]news.Item, error) {
+	var items []news.Item
+
+	err := a.db.View(func(tx *bolt.Tx) error {
+		source := tx.Bucket(bucketIndex).Bucket(sourceKey(provider, category))
+		if source == nil {
+			return nil
+		}
+
+		records := tx.Bucket(bucketItems)
+
+		end := query.Until
+		if query.Cursor != nil && (end.IsZero() || query.Cursor.DateTime.Before(end)) {
+			end = query.Cursor.DateTime
+		}
+
+		var start []byte
+		if !query.Since.IsZero() {
+			start = indexKey(query.Since, nil)
+		}
+
+		// the date of the last item read
+		var last []byte
+
+		c := source.Cursor()
+		k, _ := c.Last()
+		if !end.IsZero() {
+			k = seekBefore(c, end.Add(time.Nanosecond))
+		}
+		for ; k != nil; k, _ = c.Prev() {
+			if start != nil && bytes.Compare(k, start) < 0 {
+				break
+			}
+
+			// items published at the same time are read in descending ID order, so
+			// the whole group is read before the limit is applied to keep the lowest IDs
+			if query.Limit > 0 && len(items) >= query.Limit && !bytes.Equal(k[:8], last) {
+				break
+			}
+
+			b := records.Get(k[8:])
+			if b == nil {
+				continue
+			}
+
+			var r record
+			if err := json.Unmarshal(b, &r); err != nil {
+				return fmt.Errorf("failed to unmarshal record: %w", err)
+			}
+
+			// items published at the same time as the cursor may come before it
+			if query.Cursor != nil && !query.Cursor.Before(r.Item) {
+				continue
+			}
+
+			// an item appearing in several categories is returned for each of them
+			r.Item.Category = category
+			items = append(items, r.Item)
+			last = k[:8]
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+
+	reverseTies(items)
+	if query.Limit > 0 && len(items) > query.Limit {
+		items = items[:query.Limit]
+	}
+
+	return items, nil
+}
+
+// reverseTies reverses each group of items published at the same time, so that items
+// read in descending ID order from the index are ordered by ID, as they are in a feed.
+func reverseTies(items []news.Item) {
+	for i := 0; i < len(items); {
+		j := i + 1
+		for j < len(items) && items[j].DateTime.Equal(items[i].DateTime) {
+			j++
+		}
+		for l, r := i, j-1; l < r; l, r = l+1, r-1 {
+			items[l], items[r] = items[r], items[l]
+		}
+		i = j
+	}
+}
+
+// seekBefore moves c to the last key of an item published before dateTime, returning
+// the key, or nil if there isn't one.
+func seekBefore(c *bolt.Cursor, dateTime time.Time) []byte {
+	if k, _ := c.Seek(indexKey(dateTime, nil)); k == nil {
+		// every item was published before dateTime
+		k, _ = c.Last()
+		return k
+	}
+
+	k, _ := c.Prev()
+	return k
+}
+
+func sourceKey(provider news.Provider, category news.Category) []byte {
+	return []byte(string(provider) + "\x00" + string(category))
+}
+
+// indexKey returns the index key of an item, made of its date followed by its ID.
+// The sign bit of the date is flipped so that the keys sort in date order.
+func indexKey(dateTime time.Time, id []byte) []byte {
+	key := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(dateTime.UnixNano())^(1<<63))
+	return append(key, id...)
+}
+
+func addCategory(categories []news.Category, category news.Category) []news.Category {
+	for _, c := range categories {
+		if c == category {
+			return categories
+		}
+	}
+	return append(categories, category)
+}
@@ -0,0 +1,21 @@
+package archive
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Seen returns when the item with id was first and last seen.
+func (a *archive) Seen(id string) (firstSeen, lastSeen time.Time, err error) {
+	err = a.db.View(func(tx *bolt.Tx) error {
+		var r record
+		if err := json.Unmarshal(tx.Bucket(bucketItems).Get([]byte(id)), &r); err != nil {
+			return err
+		}
+		firstSeen, lastSeen = r.FirstSeen, r.LastSeen
+		return nil
+	})
+	return firstSeen, lastSeen, err
+}
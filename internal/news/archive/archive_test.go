@@ -0,0 +1,417 @@
+package archive_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider_mock "github.com/cshep4/news-api/internal/mock/provider"
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/news/archive"
+	service "github.com/cshep4/news-api/internal/news/service"
+)
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+type archiveFunc func([]news.Item) error
+
+func (f archiveFunc) Record(items []news.Item) error { return f(items) }
+
+func tempPath(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "archive")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return filepath.Join(dir, "archive.db")
+}
+
+func TestNew_Error(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		path                   string
+		clock                  clockwork.Clock
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "path is empty",
+			path:                   "",
+			clock:                  clockwork.NewFakeClock(),
+			expectedErrorParameter: "path",
+		},
+		{
+			name:                   "clock is empty",
+			path:                   "archive.db",
+			clock:                  nil,
+			expectedErrorParameter: "clock",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := archive.New(tc.path, tc.clock)
+			require.Error(t, err)
+			require.Nil(t, a)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestArchive_Items(t *testing.T) {
+	now := time.Date(2021, 2, 6, 20, 0, 0, 0, time.UTC)
+
+	item := func(guid string, category news.Category, age time.Duration) news.Item {
+		return news.Item{
+			Provider: news.ProviderBBC,
+			Category: category,
+			GUID:     guid,
+			Title:    guid,
+			DateTime: now.Add(-age),
+		}
+	}
+
+	// items published at the same time are ordered by ID
+	same, sameLater := item("5", news.CategoryUK, time.Hour), item("6", news.CategoryUK, time.Hour)
+	if sameLater.ID() < same.ID() {
+		same, sameLater = sameLater, same
+	}
+
+	cursor := func(i news.Item) *news.Cursor {
+		c := news.NewCursor(i)
+		return &c
+	}
+
+	testCases := []struct {
+		name          string
+		records       [][]news.Item
+		category      news.Category
+		query         archive.Query
+		expectedItems []news.Item
+	}{
+		{
+			name:     "no items",
+			category: news.CategoryUK,
+		},
+		{
+			name: "items newest first",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour)},
+				{item("3", news.CategoryUK, 3*time.Hour), item("4", news.CategoryTechnology, 0)},
+			},
+			category: news.CategoryUK,
+			expectedItems: []news.Item{
+				item("2", news.CategoryUK, time.Hour),
+				item("1", news.CategoryUK, 2*time.Hour),
+				item("3", news.CategoryUK, 3*time.Hour),
+			},
+		},
+		{
+			name: "limit",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour)},
+			},
+			category:      news.CategoryUK,
+			query:         archive.Query{Limit: 1},
+			expectedItems: []news.Item{item("2", news.CategoryUK, time.Hour)},
+		},
+		{
+			name: "items published at the same time ordered by ID",
+			records: [][]news.Item{
+				{sameLater, item("1", news.CategoryUK, 2*time.Hour), same},
+			},
+			category:      news.CategoryUK,
+			expectedItems: []news.Item{same, sameLater, item("1", news.CategoryUK, 2*time.Hour)},
+		},
+		{
+			name: "limit within items published at the same time",
+			records: [][]news.Item{
+				{sameLater, item("1", news.CategoryUK, 2*time.Hour), same},
+			},
+			category:      news.CategoryUK,
+			query:         archive.Query{Limit: 1},
+			expectedItems: []news.Item{same},
+		},
+		{
+			name: "after cursor",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour), item("3", news.CategoryUK, 3*time.Hour)},
+			},
+			category: news.CategoryUK,
+			query:    archive.Query{Cursor: cursor(item("2", news.CategoryUK, time.Hour))},
+			expectedItems: []news.Item{
+				item("1", news.CategoryUK, 2*time.Hour),
+				item("3", news.CategoryUK, 3*time.Hour),
+			},
+		},
+		{
+			name: "after cursor with limit",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour), item("3", news.CategoryUK, 3*time.Hour)},
+			},
+			category:      news.CategoryUK,
+			query:         archive.Query{Cursor: cursor(item("2", news.CategoryUK, time.Hour)), Limit: 1},
+			expectedItems: []news.Item{item("1", news.CategoryUK, 2*time.Hour)},
+		},
+		{
+			name: "after cursor published at the same time",
+			records: [][]news.Item{
+				{sameLater, same, item("1", news.CategoryUK, 2*time.Hour)},
+			},
+			category:      news.CategoryUK,
+			query:         archive.Query{Cursor: cursor(same)},
+			expectedItems: []news.Item{sameLater, item("1", news.CategoryUK, 2*time.Hour)},
+		},
+		{
+			name: "cursor newer than every item",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour)},
+			},
+			category: news.CategoryUK,
+			query:    archive.Query{Cursor: cursor(item("3", news.CategoryUK, 0))},
+			expectedItems: []news.Item{
+				item("2", news.CategoryUK, time.Hour),
+				item("1", news.CategoryUK, 2*time.Hour),
+			},
+		},
+		{
+			name: "cursor older than every item",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour)},
+			},
+			category: news.CategoryUK,
+			query:    archive.Query{Cursor: cursor(item("3", news.CategoryUK, 3*time.Hour))},
+		},
+		{
+			name: "since",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour), item("3", news.CategoryUK, 3*time.Hour)},
+			},
+			category: news.CategoryUK,
+			query:    archive.Query{Since: now.Add(-2 * time.Hour)},
+			expectedItems: []news.Item{
+				item("2", news.CategoryUK, time.Hour),
+				item("1", news.CategoryUK, 2*time.Hour),
+			},
+		},
+		{
+			name: "until",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour), item("3", news.CategoryUK, 3*time.Hour)},
+			},
+			category: news.CategoryUK,
+			query:    archive.Query{Until: now.Add(-2 * time.Hour)},
+			expectedItems: []news.Item{
+				item("1", news.CategoryUK, 2*time.Hour),
+				item("3", news.CategoryUK, 3*time.Hour),
+			},
+		},
+		{
+			name: "until with limit",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour), item("3", news.CategoryUK, 3*time.Hour)},
+			},
+			category:      news.CategoryUK,
+			query:         archive.Query{Until: now.Add(-90 * time.Minute), Limit: 1},
+			expectedItems: []news.Item{item("1", news.CategoryUK, 2*time.Hour)},
+		},
+		{
+			name: "cursor before until",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour), item("3", news.CategoryUK, 3*time.Hour)},
+			},
+			category:      news.CategoryUK,
+			query:         archive.Query{Cursor: cursor(item("1", news.CategoryUK, 2*time.Hour)), Until: now},
+			expectedItems: []news.Item{item("3", news.CategoryUK, 3*time.Hour)},
+		},
+		{
+			name: "cursor after until",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour), item("3", news.CategoryUK, 3*time.Hour)},
+			},
+			category:      news.CategoryUK,
+			query:         archive.Query{Cursor: cursor(item("4", news.CategoryUK, 0)), Until: now.Add(-150 * time.Minute)},
+			expectedItems: []news.Item{item("3", news.CategoryUK, 3*time.Hour)},
+		},
+		{
+			name: "updated item",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, 2*time.Hour), item("2", news.CategoryUK, time.Hour)},
+				{item("1", news.CategoryUK, 0)},
+			},
+			category: news.CategoryUK,
+			expectedItems: []news.Item{
+				item("1", news.CategoryUK, 0),
+				item("2", news.CategoryUK, time.Hour),
+			},
+		},
+		{
+			name: "item in several categories",
+			records: [][]news.Item{
+				{item("1", news.CategoryUK, time.Hour)},
+				{item("1", news.CategoryTechnology, time.Hour)},
+			},
+			category:      news.CategoryUK,
+			expectedItems: []news.Item{item("1", news.CategoryUK, time.Hour)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := archive.New(tempPath(t), clockwork.NewFakeClock())
+			require.NoError(t, err)
+			defer a.Close()
+
+			for _, r := range tc.records {
+				require.NoError(t, a.Record(r))
+			}
+
+			items, err := a.Items(news.ProviderBBC, tc.category, tc.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedItems, items)
+		})
+	}
+}
+
+func TestArchive_Record(t *testing.T) {
+	path := tempPath(t)
+	clock := clockwork.NewFakeClock()
+	first := clock.Now()
+
+	item := news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "1", DateTime: first}
+
+	a, err := archive.New(path, clock)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Record([]news.Item{item}))
+	require.NoError(t, a.Close())
+
+	// the archive persists after it's reopened
+	a, err = archive.New(path, clock)
+	require.NoError(t, err)
+	defer a.Close()
+
+	clock.Advance(time.Hour)
+	require.NoError(t, a.Record([]news.Item{item}))
+
+	firstSeen, lastSeen, err := a.Seen(item.ID())
+	require.NoError(t, err)
+
+	assert.True(t, first.Equal(firstSeen))
+	assert.True(t, first.Add(time.Hour).Equal(lastSeen))
+}
+
+func TestNewRecorder_Error(t *testing.T) {
+	provider := provider_mock.NewMockProvider(nil)
+	a := archiveFunc(func([]news.Item) error { return nil })
+
+	testCases := []struct {
+		name                   string
+		provider               news.Provider
+		p                      archive.Provider
+		archive                archive.Archive
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "name is empty",
+			p:                      provider,
+			archive:                a,
+			expectedErrorParameter: "name",
+		},
+		{
+			name:                   "provider is empty",
+			provider:               news.ProviderBBC,
+			archive:                a,
+			expectedErrorParameter: "provider",
+		},
+		{
+			name:                   "archive is empty",
+			provider:               news.ProviderBBC,
+			p:                      provider,
+			expectedErrorParameter: "archive",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := archive.NewRecorder(tc.provider, tc.p, tc.archive)
+			require.Error(t, err)
+			require.Nil(t, r)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestRecorder_GetFeed(t *testing.T) {
+	feed := &news.Feed{Items: []news.Item{{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "1"}}}
+
+	testCases := []struct {
+		name            string
+		getFeedErr      error
+		recordErr       error
+		expectedRecords [][]news.Item
+		expectedErr     error
+	}{
+		{
+			name:            "feed recorded",
+			expectedRecords: [][]news.Item{feed.Items},
+		},
+		{
+			name:            "feed returned when it can't be recorded",
+			recordErr:       testError("error"),
+			expectedRecords: [][]news.Item{feed.Items},
+		},
+		{
+			name:        "feed error",
+			getFeedErr:  testError("error"),
+			expectedErr: testError("error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			provider := provider_mock.NewMockProvider(ctrl)
+			provider.EXPECT().GetFeed(ctx, news.CategoryUK).Return(feed, tc.getFeedErr)
+
+			var records [][]news.Item
+			r, err := archive.NewRecorder(news.ProviderBBC, provider, archiveFunc(func(items []news.Item) error {
+				records = append(records, items)
+				return tc.recordErr
+			}))
+			require.NoError(t, err)
+
+			assert.Implements(t, (*service.Provider)(nil), r)
+
+			res, err := r.GetFeed(ctx, news.CategoryUK)
+			assert.Equal(t, tc.expectedRecords, records)
+
+			if tc.expectedErr != nil {
+				require.Equal(t, tc.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, feed, res)
+		})
+	}
+}
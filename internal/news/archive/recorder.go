@@ -0,0 +1,60 @@
+package archive
+
+import (
+	"context"
+
+	"github.com/cshep4/news-api/internal/log"
+	"github.com/cshep4/news-api/internal/news"
+)
+
+type (
+	Provider interface {
+		GetFeed(ctx context.Context, category news.Category) (*news.Feed, error)
+	}
+
+	Archive interface {
+		Record(items []news.Item) error
+	}
+
+	recorder struct {
+		name     news.Provider
+		provider Provider
+		archive  Archive
+	}
+)
+
+// NewRecorder wraps provider so that the items of every feed it returns are archived.
+// A feed is still returned if it can't be archived.
+func NewRecorder(name news.Provider, p Provider, a Archive) (*recorder, error) {
+	switch {
+	case name == "":
+		return nil, news.InvalidParameterError{Parameter: "name"}
+	case p == nil:
+		return nil, news.InvalidParameterError{Parameter: "provider"}
+	case a == nil:
+		return nil, news.InvalidParameterError{Parameter: "archive"}
+	}
+
+	return &recorder{
+		name:     name,
+		provider: p,
+		archive:  a,
+	}, nil
+}
+
+func (r *recorder) GetFeed(ctx context.Context, category news.Category) (*news.Feed, error) {
+	feed, err := r.provider.GetFeed(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.archive.Record(feed.Items); err != nil {
+		log.Error(ctx, "error_archiving_feed",
+			log.SafeParam("provider", r.name),
+			log.SafeParam("category", category),
+			log.ErrorParam(err),
+		)
+	}
+
+	return feed, nil
+}
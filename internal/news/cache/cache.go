@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"container/list"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -10,63 +12,211 @@ import (
 	"github.com/cshep4/news-api/internal/news"
 )
 
-type cache struct {
-	mutex sync.Mutex
-	clock clockwork.Clock
-	feeds map[string]news.Feed
-}
+const (
+	defaultTTL        = time.Minute
+	defaultMaxStale   = time.Hour
+	defaultMaxEntries = 1000
+	defaultMaxBytes   = 64 << 20
+)
+
+type (
+	// Stats are counters describing the use of the cache.
+	Stats struct {
+		// Hits is the number of fresh feeds returned by Get.
+		Hits uint64 `json:"hits"`
+		// StaleHits is the number of stale feeds returned by GetStale.
+		StaleHits uint64 `json:"staleHits"`
+		// Misses is the number of calls to Get that didn't find a fresh feed.
+		Misses uint64 `json:"misses"`
+		// Evictions is the number of feeds removed to make room for others.
+		Evictions uint64 `json:"evictions"`
+		// Expirations is the number of feeds removed after their max stale age.
+		Expirations uint64 `json:"expirations"`
+		Entries     int    `json:"entries"`
+		Bytes       int64  `json:"bytes"`
+	}
+
+	cache struct {
+		mutex      sync.Mutex
+		clock      clockwork.Clock
+		feeds      map[string]*list.Element
+		lru        *list.List
+		bytes      int64
+		stats      Stats
+		defaultTTL time.Duration
+		maxStale   time.Duration
+		maxEntries int
+		maxBytes   int64
+	}
+
+	// entry is a cached feed, which is fresh until expires and then served as stale
+	// for up to the max stale age, after which it's removed the next time it's seen.
+	entry struct {
+		hash    string
+		feed    news.Feed
+		expires time.Time
+		size    int64
+	}
+)
 
-func New(clock clockwork.Clock) (*cache, error) {
+// New creates an in-memory cache holding up to a max number of entries and bytes.
+// When either limit is reached the least recently used feeds are evicted.
+func New(clock clockwork.Clock, opts ...Option) (*cache, error) {
 	if clock == nil {
 		return nil, news.InvalidParameterError{Parameter: "clock"}
 	}
 
-	return &cache{
-		clock: clock,
-		mutex: sync.Mutex{},
-		feeds: make(map[string]news.Feed),
-	}, nil
+	c := &cache{
+		clock:      clock,
+		mutex:      sync.Mutex{},
+		feeds:      make(map[string]*list.Element),
+		lru:        list.New(),
+		defaultTTL: defaultTTL,
+		maxStale:   defaultMaxStale,
+		maxEntries: defaultMaxEntries,
+		maxBytes:   defaultMaxBytes,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	switch {
+	case c.defaultTTL <= 0:
+		return nil, news.InvalidParameterError{Parameter: "defaultTTL"}
+	case c.maxStale < 0:
+		return nil, news.InvalidParameterError{Parameter: "maxStale"}
+	case c.maxEntries <= 0:
+		return nil, news.InvalidParameterError{Parameter: "maxEntries"}
+	case c.maxBytes <= 0:
+		return nil, news.InvalidParameterError{Parameter: "maxBytes"}
+	}
+
+	return c, nil
 }
 
+// Get returns the cached feed for provider and category if it's still fresh.
 func (c *cache) Get(provider news.Provider, category news.Category) (*news.Feed, bool) {
 	hash := c.hash(provider, category)
 
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	feed, ok := c.feeds[hash]
-	if !ok {
+	e, ok := c.get(hash)
+	if !ok || !c.clock.Now().Before(e.expires) {
+		c.stats.Misses++
 		return nil, false
 	}
 
-	return &feed, true
+	c.stats.Hits++
+
+	return &e.feed, true
 }
 
+// GetStale returns the cached feed for provider and category if its TTL has passed
+// but it's within the max stale age.
+func (c *cache) GetStale(provider news.Provider, category news.Category) (*news.Feed, bool) {
+	hash := c.hash(provider, category)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.get(hash)
+	if !ok || c.clock.Now().Before(e.expires) {
+		return nil, false
+	}
+
+	c.stats.StaleHits++
+
+	return &e.feed, true
+}
+
+// Store caches feed for its TTL, or the default TTL if it doesn't set one, replacing
+// any feed already cached for provider and category. The feed is kept until it has
+// been stale for the max stale age, or it's evicted to make room for other feeds.
 func (c *cache) Store(provider news.Provider, category news.Category, feed news.Feed) {
+	ttl := time.Minute * time.Duration(feed.TTL)
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+
+	c.StoreUntil(provider, category, feed, c.clock.Now().Add(ttl))
+}
+
+// StoreUntil caches feed until expires rather than for its TTL, for feeds whose
+// expiry is already known, e.g. when they're copied from another cache.
+func (c *cache) StoreUntil(provider news.Provider, category news.Category, feed news.Feed, expires time.Time) {
 	hash := c.hash(provider, category)
+	size := sizeOf(feed)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if el, ok := c.feeds[hash]; ok {
+		c.remove(el)
+	}
+
+	c.feeds[hash] = c.lru.PushFront(&entry{
+		hash:    hash,
+		feed:    feed,
+		expires: expires,
+		size:    size,
+	})
+	c.bytes += size
+
+	for c.lru.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
 
-	c.put(hash, feed)
+// Stats returns the counters of the cache and its current size.
+func (c *cache) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-	go c.invalidateAfterTTL(hash, feed.TTL)
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.Bytes = c.bytes
+
+	return stats
 }
 
 func (c *cache) hash(provider news.Provider, category news.Category) string {
 	return fmt.Sprintf("%s-%s", provider, category)
 }
 
-func (c *cache) put(hash string, feed news.Feed) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+// get returns the entry for hash and marks it as recently used. An entry that has
+// been stale for longer than the max stale age is removed instead.
+func (c *cache) get(hash string) (*entry, bool) {
+	el, ok := c.feeds[hash]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if !c.clock.Now().Before(e.expires.Add(c.maxStale)) {
+		c.remove(el)
+		c.stats.Expirations++
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
 
-	c.feeds[hash] = feed
+	return e, true
 }
 
-func (c *cache) invalidateAfterTTL(hash string, ttl int) {
-	select {
-	case <-c.clock.After(time.Minute * time.Duration(ttl)):
-		c.mutex.Lock()
-		defer c.mutex.Unlock()
+func (c *cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	delete(c.feeds, e.hash)
+	c.bytes -= e.size
+}
 
-		delete(c.feeds, hash)
+// sizeOf approximates the memory used by feed with the size of its JSON encoding.
+func sizeOf(feed news.Feed) int64 {
+	b, err := json.Marshal(feed)
+	if err != nil {
+		return 0
 	}
+	return int64(len(b))
 }
@@ -0,0 +1,3 @@
+package cache
+
+var SizeOf = sizeOf
@@ -1,7 +1,6 @@
 package cache_test
 
 import (
-	"sync"
 	"testing"
 	"time"
 
@@ -18,6 +17,7 @@ func TestNew_Error(t *testing.T) {
 	testCases := []struct {
 		name                   string
 		clock                  clockwork.Clock
+		opts                   []cache.Option
 		expectedErrorParameter string
 	}{
 		{
@@ -25,11 +25,35 @@ func TestNew_Error(t *testing.T) {
 			clock:                  nil,
 			expectedErrorParameter: "clock",
 		},
+		{
+			name:                   "default ttl is invalid",
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []cache.Option{cache.WithDefaultTTL(0)},
+			expectedErrorParameter: "defaultTTL",
+		},
+		{
+			name:                   "max stale is invalid",
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []cache.Option{cache.WithMaxStale(-time.Second)},
+			expectedErrorParameter: "maxStale",
+		},
+		{
+			name:                   "max entries is invalid",
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []cache.Option{cache.WithMaxEntries(0)},
+			expectedErrorParameter: "maxEntries",
+		},
+		{
+			name:                   "max bytes is invalid",
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []cache.Option{cache.WithMaxBytes(0)},
+			expectedErrorParameter: "maxBytes",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			cache, err := cache.New(tc.clock)
+			cache, err := cache.New(tc.clock, tc.opts...)
 			require.Error(t, err)
 			require.Nil(t, cache)
 
@@ -143,22 +167,9 @@ func TestCache_Store(t *testing.T) {
 			cache, err := cache.New(clock)
 			require.NoError(t, err)
 			require.NotNil(t, cache)
-			
-			var wg sync.WaitGroup
-			wg.Add(1)
-			go func() {
-				cache.Store(provider, category, feed)
-				wg.Done()
-			}()
-
-			clock.BlockUntil(1)
-			clock.Advance(time.Minute * time.Duration(tc.advanceTime))
-
-			// have to sleep to ensure cache invalidation has had chance to run as
-			// this is inside a go routine
-			time.Sleep(5 * time.Millisecond)
 
-			wg.Wait()
+			cache.Store(provider, category, feed)
+			clock.Advance(time.Minute * time.Duration(tc.advanceTime))
 
 			res, ok := cache.Get(provider, category)
 			require.Equal(t, tc.expectedExists, ok)
@@ -167,3 +178,200 @@ func TestCache_Store(t *testing.T) {
 		})
 	}
 }
+
+func TestCache_GetStale(t *testing.T) {
+	const (
+		provider = news.Provider("provider")
+		category = news.Category("category")
+		maxStale = time.Hour
+	)
+
+	testCases := []struct {
+		name          string
+		feed          news.Feed
+		advanceTime   time.Duration
+		expectedFresh bool
+		expectedStale bool
+	}{
+		{
+			name:          "fresh feed",
+			feed:          news.Feed{Title: "feed", TTL: 10},
+			advanceTime:   5 * time.Minute,
+			expectedFresh: true,
+		},
+		{
+			name:          "stale feed after ttl",
+			feed:          news.Feed{Title: "feed", TTL: 10},
+			advanceTime:   15 * time.Minute,
+			expectedStale: true,
+		},
+		{
+			name:        "expired after max stale",
+			feed:        news.Feed{Title: "feed", TTL: 10},
+			advanceTime: 10*time.Minute + maxStale,
+		},
+		{
+			name:          "default ttl when feed has none",
+			feed:          news.Feed{Title: "feed"},
+			advanceTime:   30 * time.Second,
+			expectedFresh: true,
+		},
+		{
+			name:          "stale after default ttl",
+			feed:          news.Feed{Title: "feed"},
+			advanceTime:   2 * time.Minute,
+			expectedStale: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := clockwork.NewFakeClock()
+
+			cache, err := cache.New(clock, cache.WithDefaultTTL(time.Minute), cache.WithMaxStale(maxStale))
+			require.NoError(t, err)
+			require.NotNil(t, cache)
+
+			cache.Store(provider, category, tc.feed)
+			clock.Advance(tc.advanceTime)
+
+			_, fresh := cache.Get(provider, category)
+			assert.Equal(t, tc.expectedFresh, fresh)
+
+			res, stale := cache.GetStale(provider, category)
+			require.Equal(t, tc.expectedStale, stale)
+
+			if tc.expectedStale {
+				assert.Equal(t, &tc.feed, res)
+			}
+		})
+	}
+}
+
+func TestCache_Store_Replace(t *testing.T) {
+	const (
+		provider = news.Provider("provider")
+		category = news.Category("category")
+	)
+
+	clock := clockwork.NewFakeClock()
+
+	cache, err := cache.New(clock, cache.WithMaxStale(time.Hour))
+	require.NoError(t, err)
+	require.NotNil(t, cache)
+
+	cache.Store(provider, category, news.Feed{Title: "old", TTL: 1})
+	cache.Store(provider, category, news.Feed{Title: "new", TTL: 10})
+
+	clock.Advance(time.Minute + time.Hour)
+
+	res, ok := cache.GetStale(provider, category)
+	require.True(t, ok)
+
+	assert.Equal(t, &news.Feed{Title: "new", TTL: 10}, res)
+}
+
+func TestCache_Evict(t *testing.T) {
+	const provider = news.Provider("provider")
+
+	feed := news.Feed{Title: "feed"}
+	size := cache.SizeOf(feed)
+
+	testCases := []struct {
+		name              string
+		opts              []cache.Option
+		expectedCached    []news.Category
+		expectedEvicted   []news.Category
+		expectedEvictions uint64
+	}{
+		{
+			name:              "evict least recently used when max entries reached",
+			opts:              []cache.Option{cache.WithMaxEntries(2)},
+			expectedCached:    []news.Category{"a", "c"},
+			expectedEvicted:   []news.Category{"b"},
+			expectedEvictions: 1,
+		},
+		{
+			name:              "evict least recently used when max bytes reached",
+			opts:              []cache.Option{cache.WithMaxBytes(2 * size)},
+			expectedCached:    []news.Category{"a", "c"},
+			expectedEvicted:   []news.Category{"b"},
+			expectedEvictions: 1,
+		},
+		{
+			name:              "feed larger than max bytes",
+			opts:              []cache.Option{cache.WithMaxBytes(size - 1)},
+			expectedEvicted:   []news.Category{"a", "b", "c"},
+			expectedEvictions: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache, err := cache.New(clockwork.NewFakeClock(), tc.opts...)
+			require.NoError(t, err)
+			require.NotNil(t, cache)
+
+			cache.Store(provider, "a", feed)
+			cache.Store(provider, "b", feed)
+
+			// a is used, so b is the least recently used
+			cache.Get(provider, "a")
+			cache.Store(provider, "c", feed)
+
+			for _, c := range tc.expectedCached {
+				_, ok := cache.Get(provider, c)
+				assert.True(t, ok, c)
+			}
+			for _, c := range tc.expectedEvicted {
+				_, ok := cache.Get(provider, c)
+				assert.False(t, ok, c)
+			}
+
+			stats := cache.Stats()
+			assert.Equal(t, tc.expectedEvictions, stats.Evictions)
+			assert.Equal(t, len(tc.expectedCached), stats.Entries)
+			assert.Equal(t, int64(len(tc.expectedCached))*size, stats.Bytes)
+		})
+	}
+}
+
+func TestCache_Stats(t *testing.T) {
+	const (
+		provider = news.Provider("provider")
+		category = news.Category("category")
+	)
+	feed := news.Feed{Title: "feed", TTL: 1}
+
+	clock := clockwork.NewFakeClock()
+
+	c, err := cache.New(clock, cache.WithMaxStale(time.Hour))
+	require.NoError(t, err)
+	require.NotNil(t, c)
+
+	c.Store(provider, category, feed)
+	c.Store(provider, category, feed)
+	c.Get(provider, category)
+	c.Get(provider, "missing")
+
+	assert.Equal(t, cache.Stats{
+		Hits:    1,
+		Misses:  1,
+		Entries: 1,
+		Bytes:   cache.SizeOf(feed),
+	}, c.Stats())
+
+	clock.Advance(time.Minute)
+	c.Get(provider, category)
+	c.GetStale(provider, category)
+
+	clock.Advance(time.Hour)
+	c.GetStale(provider, category)
+
+	assert.Equal(t, cache.Stats{
+		Hits:        1,
+		StaleHits:   1,
+		Misses:      2,
+		Expirations: 1,
+	}, c.Stats())
+}
@@ -0,0 +1,35 @@
+package cache
+
+import "time"
+
+type Option func(*cache)
+
+// WithDefaultTTL sets how long feeds that don't specify a TTL are fresh for.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(c *cache) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithMaxStale sets how long a feed is kept after its TTL has passed, so that it
+// can be served while it's refreshed or if the refresh fails.
+func WithMaxStale(maxStale time.Duration) Option {
+	return func(c *cache) {
+		c.maxStale = maxStale
+	}
+}
+
+// WithMaxEntries sets the max number of feeds that are cached.
+func WithMaxEntries(entries int) Option {
+	return func(c *cache) {
+		c.maxEntries = entries
+	}
+}
+
+// WithMaxBytes sets the max total size of the cached feeds, measured as the size of
+// their JSON encoding.
+func WithMaxBytes(bytes int64) Option {
+	return func(c *cache) {
+		c.maxBytes = bytes
+	}
+}
@@ -0,0 +1,249 @@
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/cshep4/news-api/internal/log"
+	"github.com/cshep4/news-api/internal/news"
+)
+
+const (
+	defaultPrefix   = "news-api:"
+	defaultTTL      = time.Minute
+	defaultMaxStale = time.Hour
+)
+
+type (
+	Pool interface {
+		Get() redigo.Conn
+	}
+
+	// Stats count the lookups made by this instance. They aren't shared with the
+	// other instances using the same Redis store, and Redis does its own evictions and
+	// expirations, so unlike the in-memory cache there are no size counters.
+	Stats struct {
+		Hits      uint64 `json:"hits"`
+		StaleHits uint64 `json:"staleHits"`
+		// Misses include keys that couldn't be read or decoded.
+		Misses uint64 `json:"misses"`
+		// Errors is the number of failed requests to Redis and undecodable records.
+		Errors uint64 `json:"errors"`
+	}
+
+	cache struct {
+		pool       Pool
+		clock      clockwork.Clock
+		ctx        context.Context
+		prefix     string
+		defaultTTL time.Duration
+		maxStale   time.Duration
+
+		mutex sync.Mutex
+		stats Stats
+	}
+
+	// record is the value stored for a feed, which is fresh until Expires and then
+	// served as stale until Redis expires the key.
+	record struct {
+		Feed    news.Feed `json:"feed"`
+		Expires time.Time `json:"expires"`
+	}
+)
+
+// New creates a cache backed by a Redis-protocol store, so that it can be shared by
+// several instances of the service. Feeds are stored as gzipped JSON, and their keys
+// expire once they've been stale for the max stale age.
+func New(pool Pool, clock clockwork.Clock, opts ...Option) (*cache, error) {
+	switch {
+	case pool == nil:
+		return nil, news.InvalidParameterError{Parameter: "pool"}
+	case clock == nil:
+		return nil, news.InvalidParameterError{Parameter: "clock"}
+	}
+
+	c := &cache{
+		pool:       pool,
+		clock:      clock,
+		ctx:        context.Background(),
+		prefix:     defaultPrefix,
+		defaultTTL: defaultTTL,
+		maxStale:   defaultMaxStale,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	switch {
+	case c.defaultTTL <= 0:
+		return nil, news.InvalidParameterError{Parameter: "defaultTTL"}
+	case c.maxStale < 0:
+		return nil, news.InvalidParameterError{Parameter: "maxStale"}
+	}
+
+	return c, nil
+}
+
+// Get returns the feed stored under the key of provider and category if the
+// expiry in its record hasn't passed. The key itself lives on until the feed has
+// been stale for the max stale age.
+func (c *cache) Get(provider news.Provider, category news.Category) (*news.Feed, bool) {
+	feed, expires, ok := c.Lookup(provider, category)
+	if !ok || !c.clock.Now().Before(expires) {
+		c.count(func(s *Stats) { s.Misses++ })
+		return nil, false
+	}
+
+	c.count(func(s *Stats) { s.Hits++ })
+
+	return feed, true
+}
+
+// GetStale returns the feed stored under the key of provider and category once the
+// expiry in its record has passed, for as long as Redis keeps the key.
+func (c *cache) GetStale(provider news.Provider, category news.Category) (*news.Feed, bool) {
+	feed, expires, ok := c.Lookup(provider, category)
+	if !ok || c.clock.Now().Before(expires) {
+		return nil, false
+	}
+
+	c.count(func(s *Stats) { s.StaleHits++ })
+
+	return feed, true
+}
+
+// Lookup returns the cached feed for provider and category, whether it's fresh or
+// stale, and the time it stops being fresh.
+func (c *cache) Lookup(provider news.Provider, category news.Category) (*news.Feed, time.Time, bool) {
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	b, err := redigo.Bytes(conn.Do("GET", c.key(provider, category)))
+	switch {
+	case err == redigo.ErrNil:
+		return nil, time.Time{}, false
+	case err != nil:
+		c.logError("error_getting_cached_feed", provider, category, err)
+		return nil, time.Time{}, false
+	}
+
+	r, err := decode(b)
+	if err != nil {
+		c.logError("error_decoding_cached_feed", provider, category, err)
+		return nil, time.Time{}, false
+	}
+
+	// the key may outlive the max stale age if it was stored with a longer one
+	if !c.clock.Now().Before(r.Expires.Add(c.maxStale)) {
+		return nil, time.Time{}, false
+	}
+
+	return &r.Feed, r.Expires, true
+}
+
+// Store caches feed for its TTL, or the default TTL if it doesn't set one.
+func (c *cache) Store(provider news.Provider, category news.Category, feed news.Feed) {
+	ttl := time.Minute * time.Duration(feed.TTL)
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+
+	c.StoreUntil(provider, category, feed, c.clock.Now().Add(ttl))
+}
+
+// StoreUntil caches feed until expires rather than for its TTL. The key expires once
+// the feed has been stale for the max stale age.
+func (c *cache) StoreUntil(provider news.Provider, category news.Category, feed news.Feed, expires time.Time) {
+	keyTTL := expires.Add(c.maxStale).Sub(c.clock.Now())
+	if keyTTL < time.Millisecond {
+		return
+	}
+
+	b, err := encode(record{Feed: feed, Expires: expires})
+	if err != nil {
+		c.logError("error_encoding_cached_feed", provider, category, err)
+		return
+	}
+
+	conn := c.pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("SET", c.key(provider, category), b, "PX", keyTTL.Milliseconds()); err != nil {
+		c.logError("error_storing_cached_feed", provider, category, err)
+	}
+}
+
+// Stats returns the counters of the cache.
+func (c *cache) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.stats
+}
+
+// key returns the key of the feed of provider and category, which is unambiguous as
+// provider and category names can't contain ":".
+func (c *cache) key(provider news.Provider, category news.Category) string {
+	return fmt.Sprintf("%s%s:%s", c.prefix, provider, category)
+}
+
+func (c *cache) count(f func(*Stats)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	f(&c.stats)
+}
+
+func (c *cache) logError(msg string, provider news.Provider, category news.Category, err error) {
+	c.count(func(s *Stats) { s.Errors++ })
+
+	log.Error(c.ctx, msg,
+		log.SafeParam("provider", provider),
+		log.SafeParam("category", category),
+		log.ErrorParam(err),
+	)
+}
+
+func encode(r record) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(w).Encode(r); err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress feed: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decode(b []byte) (*record, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress feed: %w", err)
+	}
+	defer r.Close()
+
+	b, err = ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress feed: %w", err)
+	}
+
+	var rec record
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	return &rec, nil
+}
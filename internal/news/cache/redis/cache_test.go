@@ -0,0 +1,227 @@
+package redis_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/news/cache/redis"
+	service "github.com/cshep4/news-api/internal/news/service"
+)
+
+const (
+	provider = news.Provider("provider")
+	category = news.Category("category")
+)
+
+func newPool(t *testing.T) (*miniredis.Miniredis, *redigo.Pool) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	addr := mr.Addr()
+	pool := &redigo.Pool{
+		Dial: func() (redigo.Conn, error) {
+			return redigo.Dial("tcp", addr)
+		},
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	return mr, pool
+}
+
+func TestNew_Error(t *testing.T) {
+	_, pool := newPool(t)
+
+	testCases := []struct {
+		name                   string
+		pool                   redis.Pool
+		clock                  clockwork.Clock
+		opts                   []redis.Option
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "pool is empty",
+			pool:                   nil,
+			clock:                  clockwork.NewFakeClock(),
+			expectedErrorParameter: "pool",
+		},
+		{
+			name:                   "clock is empty",
+			pool:                   pool,
+			clock:                  nil,
+			expectedErrorParameter: "clock",
+		},
+		{
+			name:                   "default ttl is invalid",
+			pool:                   pool,
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []redis.Option{redis.WithDefaultTTL(0)},
+			expectedErrorParameter: "defaultTTL",
+		},
+		{
+			name:                   "max stale is invalid",
+			pool:                   pool,
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []redis.Option{redis.WithMaxStale(-time.Second)},
+			expectedErrorParameter: "maxStale",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache, err := redis.New(tc.pool, tc.clock, tc.opts...)
+			require.Error(t, err)
+			require.Nil(t, cache)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestNew_Success(t *testing.T) {
+	_, pool := newPool(t)
+
+	cache, err := redis.New(pool, clockwork.NewFakeClock())
+	require.NoError(t, err)
+	require.NotNil(t, cache)
+
+	assert.Implements(t, (*service.Cache)(nil), cache)
+}
+
+func TestCache_GetStale(t *testing.T) {
+	const maxStale = time.Hour
+
+	testCases := []struct {
+		name          string
+		feed          news.Feed
+		advanceTime   time.Duration
+		expectedFresh bool
+		expectedStale bool
+	}{
+		{
+			name:          "fresh feed",
+			feed:          news.Feed{Title: "feed", TTL: 10, Items: []news.Item{{Title: "item"}}},
+			advanceTime:   5 * time.Minute,
+			expectedFresh: true,
+		},
+		{
+			name:          "stale feed after ttl",
+			feed:          news.Feed{Title: "feed", TTL: 10, Items: []news.Item{{Title: "item"}}},
+			advanceTime:   15 * time.Minute,
+			expectedStale: true,
+		},
+		{
+			name:        "expired after max stale",
+			feed:        news.Feed{Title: "feed", TTL: 10},
+			advanceTime: 10*time.Minute + maxStale,
+		},
+		{
+			name:          "default ttl when feed has none",
+			feed:          news.Feed{Title: "feed"},
+			advanceTime:   30 * time.Second,
+			expectedFresh: true,
+		},
+		{
+			name:          "stale after default ttl",
+			feed:          news.Feed{Title: "feed"},
+			advanceTime:   2 * time.Minute,
+			expectedStale: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mr, pool := newPool(t)
+			clock := clockwork.NewFakeClock()
+
+			cache, err := redis.New(pool, clock, redis.WithDefaultTTL(time.Minute), redis.WithMaxStale(maxStale))
+			require.NoError(t, err)
+			require.NotNil(t, cache)
+
+			cache.Store(provider, category, tc.feed)
+			clock.Advance(tc.advanceTime)
+			mr.FastForward(tc.advanceTime)
+
+			res, fresh := cache.Get(provider, category)
+			require.Equal(t, tc.expectedFresh, fresh)
+
+			if tc.expectedFresh {
+				assert.Equal(t, &tc.feed, res)
+			}
+
+			res, stale := cache.GetStale(provider, category)
+			require.Equal(t, tc.expectedStale, stale)
+
+			if tc.expectedStale {
+				assert.Equal(t, &tc.feed, res)
+			}
+		})
+	}
+}
+
+func TestCache_Store(t *testing.T) {
+	mr, pool := newPool(t)
+	clock := clockwork.NewFakeClock()
+
+	cache, err := redis.New(pool, clock, redis.WithPrefix("test:"), redis.WithMaxStale(time.Hour))
+	require.NoError(t, err)
+
+	cache.Store(provider, category, news.Feed{Title: "feed", TTL: 10})
+
+	assert.Equal(t, []string{"test:provider:category"}, mr.Keys())
+	assert.Equal(t, 10*time.Minute+time.Hour, mr.TTL("test:provider:category"))
+
+	// feeds stored by one instance are shared with the others
+	other, err := redis.New(pool, clock, redis.WithPrefix("test:"))
+	require.NoError(t, err)
+
+	res, ok := other.Get(provider, category)
+	require.True(t, ok)
+
+	assert.Equal(t, &news.Feed{Title: "feed", TTL: 10}, res)
+	assert.Equal(t, redis.Stats{Hits: 1}, other.Stats())
+}
+
+func TestCache_Error(t *testing.T) {
+	testCases := []struct {
+		name  string
+		setup func(mr *miniredis.Miniredis)
+	}{
+		{
+			name:  "redis unavailable",
+			setup: func(mr *miniredis.Miniredis) { mr.Close() },
+		},
+		{
+			name: "invalid value",
+			setup: func(mr *miniredis.Miniredis) {
+				require.NoError(t, mr.Set("news-api:provider:category", "invalid"))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mr, pool := newPool(t)
+
+			cache, err := redis.New(pool, clockwork.NewFakeClock())
+			require.NoError(t, err)
+
+			tc.setup(mr)
+
+			_, ok := cache.Get(provider, category)
+			require.False(t, ok)
+
+			assert.Equal(t, redis.Stats{Misses: 1, Errors: 1}, cache.Stats())
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"context"
+	"time"
+)
+
+type Option func(*cache)
+
+// WithPrefix sets the prefix of the keys feeds are stored under, defaulting to "news-api:".
+func WithPrefix(prefix string) Option {
+	return func(c *cache) {
+		c.prefix = prefix
+	}
+}
+
+// WithDefaultTTL sets the expiry recorded with feeds that don't specify a TTL. The
+// key's Redis TTL is longer, by the max stale age.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(c *cache) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithMaxStale sets how much longer than a feed's TTL its key is given in Redis, so
+// that it can still be served as stale before Redis expires it.
+func WithMaxStale(maxStale time.Duration) Option {
+	return func(c *cache) {
+		c.maxStale = maxStale
+	}
+}
+
+// WithLogContext sets the context carrying the logger that errors are logged with.
+func WithLogContext(ctx context.Context) Option {
+	return func(c *cache) {
+		c.ctx = ctx
+	}
+}
@@ -0,0 +1,92 @@
+package tiered
+
+import (
+	"time"
+
+	"github.com/cshep4/news-api/internal/news"
+)
+
+type (
+	// L1 is the local cache that's checked first.
+	L1 interface {
+		Get(provider news.Provider, category news.Category) (*news.Feed, bool)
+		GetStale(provider news.Provider, category news.Category) (*news.Feed, bool)
+		Store(provider news.Provider, category news.Category, feed news.Feed)
+		StoreUntil(provider news.Provider, category news.Category, feed news.Feed, expires time.Time)
+	}
+
+	// L2 is the shared cache that's checked when a feed isn't in the L1 cache.
+	L2 interface {
+		Lookup(provider news.Provider, category news.Category) (*news.Feed, time.Time, bool)
+		Store(provider news.Provider, category news.Category, feed news.Feed)
+	}
+
+	cache struct {
+		l1 L1
+		l2 L2
+	}
+)
+
+// New creates a two-tier cache. Feeds are stored in both tiers, and a feed found
+// in the L2 cache is copied to the L1 cache with its original expiry.
+func New(l1 L1, l2 L2) (*cache, error) {
+	switch {
+	case l1 == nil:
+		return nil, news.InvalidParameterError{Parameter: "l1"}
+	case l2 == nil:
+		return nil, news.InvalidParameterError{Parameter: "l2"}
+	}
+
+	return &cache{
+		l1: l1,
+		l2: l2,
+	}, nil
+}
+
+// Get returns the feed from the L1 cache if it's fresh there. Otherwise the L1
+// cache is filled from the L2 cache, so that a feed fetched by another instance is
+// used, and the L1 cache is checked again.
+func (c *cache) Get(provider news.Provider, category news.Category) (*news.Feed, bool) {
+	if feed, ok := c.l1.Get(provider, category); ok {
+		return feed, true
+	}
+
+	if !c.fill(provider, category) {
+		return nil, false
+	}
+
+	return c.l1.Get(provider, category)
+}
+
+// GetStale is the stale counterpart of Get, filling the L1 cache from the L2 cache
+// when the L1 cache has no stale feed. The max stale age of the L1 cache applies.
+func (c *cache) GetStale(provider news.Provider, category news.Category) (*news.Feed, bool) {
+	if feed, ok := c.l1.GetStale(provider, category); ok {
+		return feed, true
+	}
+
+	if !c.fill(provider, category) {
+		return nil, false
+	}
+
+	return c.l1.GetStale(provider, category)
+}
+
+// Store caches feed in both tiers.
+func (c *cache) Store(provider news.Provider, category news.Category, feed news.Feed) {
+	c.l1.Store(provider, category, feed)
+	c.l2.Store(provider, category, feed)
+}
+
+// fill copies the feed for provider and category from the L2 cache to the L1 cache,
+// reporting whether it was found.
+func (c *cache) fill(provider news.Provider, category news.Category) bool {
+	feed, expires, ok := c.l2.Lookup(provider, category)
+	if !ok {
+		return false
+	}
+
+	c.l1.StoreUntil(provider, category, *feed, expires)
+
+	return true
+}
@@ -0,0 +1,138 @@
+package tiered_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/news/cache"
+	"github.com/cshep4/news-api/internal/news/cache/redis"
+	"github.com/cshep4/news-api/internal/news/cache/tiered"
+	service "github.com/cshep4/news-api/internal/news/service"
+)
+
+const (
+	provider = news.Provider("provider")
+	category = news.Category("category")
+)
+
+// newCache creates a tiered cache with a new L1 cache and an L2 cache backed by mr.
+func newCache(t *testing.T, mr *miniredis.Miniredis, clock clockwork.Clock) service.Cache {
+	pool := &redigo.Pool{
+		Dial: func() (redigo.Conn, error) {
+			return redigo.Dial("tcp", mr.Addr())
+		},
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	l1, err := cache.New(clock)
+	require.NoError(t, err)
+
+	l2, err := redis.New(pool, clock)
+	require.NoError(t, err)
+
+	c, err := tiered.New(l1, l2)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestNew_Error(t *testing.T) {
+	l1, err := cache.New(clockwork.NewFakeClock())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name                   string
+		l1                     tiered.L1
+		l2                     tiered.L2
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "l1 is empty",
+			l1:                     nil,
+			expectedErrorParameter: "l1",
+		},
+		{
+			name:                   "l2 is empty",
+			l1:                     l1,
+			l2:                     nil,
+			expectedErrorParameter: "l2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache, err := tiered.New(tc.l1, tc.l2)
+			require.Error(t, err)
+			require.Nil(t, cache)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestCache_Get(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	clock := clockwork.NewFakeClock()
+	feed := news.Feed{Title: "feed", TTL: 10}
+
+	first := newCache(t, mr, clock)
+	second := newCache(t, mr, clock)
+
+	_, ok := second.Get(provider, category)
+	require.False(t, ok)
+	_, ok = second.GetStale(provider, category)
+	require.False(t, ok)
+
+	first.Store(provider, category, feed)
+
+	res, ok := first.Get(provider, category)
+	require.True(t, ok)
+	assert.Equal(t, &feed, res)
+
+	// the second instance gets the feed from the shared cache
+	clock.Advance(5 * time.Minute)
+
+	res, ok = second.Get(provider, category)
+	require.True(t, ok)
+	assert.Equal(t, &feed, res)
+
+	// the copy keeps its original expiry
+	clock.Advance(5 * time.Minute)
+
+	_, ok = second.Get(provider, category)
+	require.False(t, ok)
+
+	res, ok = second.GetStale(provider, category)
+	require.True(t, ok)
+	assert.Equal(t, &feed, res)
+}
+
+func TestCache_GetStale(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	clock := clockwork.NewFakeClock()
+	feed := news.Feed{Title: "feed", TTL: 10}
+
+	newCache(t, mr, clock).Store(provider, category, feed)
+	clock.Advance(15 * time.Minute)
+
+	res, ok := newCache(t, mr, clock).GetStale(provider, category)
+	require.True(t, ok)
+
+	assert.Equal(t, &feed, res)
+}
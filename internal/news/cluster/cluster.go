@@ -0,0 +1,338 @@
+package cluster
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cshep4/news-api/internal/news"
+)
+
+const (
+	defaultThreshold = 0.4
+	defaultWindow    = 48 * time.Hour
+
+	// linkScore is the score of items with the same link, which is above any
+	// similarity of their words.
+	linkScore = 2
+
+	// epsilon allows for rounding when working out the prefix of a document.
+	epsilon = 1e-9
+)
+
+// stopWords are common words that don't say what a story is about.
+var stopWords = map[string]struct{}{
+	"a": {}, "about": {}, "after": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {},
+	"be": {}, "been": {}, "but": {}, "by": {}, "for": {}, "from": {}, "has": {}, "have": {},
+	"he": {}, "her": {}, "his": {}, "in": {}, "into": {}, "is": {}, "it": {}, "its": {},
+	"new": {}, "not": {}, "of": {}, "on": {}, "or": {}, "over": {}, "says": {}, "she": {},
+	"that": {}, "the": {}, "their": {}, "they": {}, "this": {}, "to": {}, "up": {}, "was": {},
+	"we": {}, "were": {}, "what": {}, "will": {}, "with": {}, "you": {},
+}
+
+type (
+	clusterer struct {
+		threshold float64
+		window    time.Duration
+	}
+
+	// document is the text of an item prepared for comparison.
+	document struct {
+		provider news.Provider
+		link     string
+		dateTime time.Time
+		// terms are the IDs of the document's terms, ordered by rarity.
+		terms []int
+	}
+
+	// link is a pair of items about the same story, scored by how similar they are.
+	link struct {
+		i, j  int
+		score float64
+	}
+
+	// forest is a union-find of the items, tracking the providers in each cluster.
+	forest struct {
+		parents   []int
+		providers []map[news.Provider]struct{}
+	}
+)
+
+// New creates a clusterer that groups items from different providers about the same
+// story. Items are about the same story if they link to the same article, or if the
+// words of their titles and descriptions are similar enough and they were published
+// within a window of each other.
+func New(opts ...Option) (*clusterer, error) {
+	c := &clusterer{
+		threshold: defaultThreshold,
+		window:    defaultWindow,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	switch {
+	case c.threshold <= 0 || c.threshold > 1:
+		return nil, news.InvalidParameterError{Parameter: "threshold"}
+	case c.window <= 0:
+		return nil, news.InvalidParameterError{Parameter: "window"}
+	}
+
+	return c, nil
+}
+
+// Cluster groups items about the same story, returning the indexes of the items in
+// each cluster of more than one item. The indexes of a cluster are in ascending
+// order, and clusters are ordered by their first index.
+func (c *clusterer) Cluster(items []news.Item) [][]int {
+	words := make([]map[string]struct{}, len(items))
+	for i, item := range items {
+		words[i] = terms(item.Title + " " + item.Description)
+	}
+	ids := termIDs(words)
+
+	docs := make([]document, len(items))
+	for i, item := range items {
+		docs[i] = document{
+			provider: item.Provider,
+			link:     news.CanonicalLink(item.Link),
+			dateTime: item.DateTime,
+			terms:    sortedIDs(words[i], ids),
+		}
+	}
+
+	// only items with the same link or a term in common in their prefixes can be
+	// similar, so the others aren't compared
+	var (
+		links      []link
+		byLink     = make(map[string][]int)
+		byTerm     = make(map[int][]int)
+		candidates []int
+		// compared is j+1 once item i is a candidate to compare with item j
+		compared = make([]int, len(docs))
+	)
+	for j, d := range docs {
+		candidates = candidates[:0]
+		add := func(others []int) {
+			for _, i := range others {
+				if compared[i] != j+1 {
+					compared[i] = j + 1
+					candidates = append(candidates, i)
+				}
+			}
+		}
+
+		if d.link != "" {
+			add(byLink[d.link])
+			byLink[d.link] = append(byLink[d.link], j)
+		}
+		for _, t := range c.prefix(d.terms) {
+			add(byTerm[t])
+			byTerm[t] = append(byTerm[t], j)
+		}
+
+		for _, i := range candidates {
+			if score, ok := c.similar(docs[i], d); ok {
+				links = append(links, link{i: i, j: j, score: score})
+			}
+		}
+	}
+
+	// the most similar items are joined first, so that when a story could join
+	// either of two clusters it joins the one it's closest to
+	sort.Slice(links, func(x, y int) bool {
+		switch {
+		case links[x].score != links[y].score:
+			return links[x].score > links[y].score
+		case links[x].i != links[y].i:
+			return links[x].i < links[y].i
+		}
+		return links[x].j < links[y].j
+	})
+
+	f := newForest(docs)
+	for _, l := range links {
+		f.union(l.i, l.j)
+	}
+
+	groups := make(map[int][]int)
+	for i := range items {
+		root := f.find(i)
+		groups[root] = append(groups[root], i)
+	}
+
+	var clusters [][]int
+	for _, g := range groups {
+		if len(g) > 1 {
+			clusters = append(clusters, g)
+		}
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i][0] < clusters[j][0]
+	})
+
+	return clusters
+}
+
+// prefix returns the rarest terms of a document, given its terms ordered by rarity.
+// Documents at least as similar as the threshold have at least threshold times as
+// many terms in common as either has terms, so with the terms of every document
+// ordered the same way, they have a term in common in their prefixes.
+func (c *clusterer) prefix(terms []int) []int {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	common := int(math.Ceil(c.threshold*float64(len(terms)) - epsilon))
+	return terms[:len(terms)-common+1]
+}
+
+// similar reports whether a and b are from different providers and about the same
+// story, returning how similar they are. Items with the same link score highest.
+func (c *clusterer) similar(a, b document) (float64, bool) {
+	switch {
+	case a.provider == b.provider:
+		return 0, false
+	case a.link != "" && a.link == b.link:
+		return linkScore, true
+	}
+
+	gap := a.dateTime.Sub(b.dateTime)
+	if gap < 0 {
+		gap = -gap
+	}
+	if gap > c.window {
+		return 0, false
+	}
+
+	score := jaccard(a.terms, b.terms)
+	return score, score >= c.threshold
+}
+
+// terms returns the distinct lower cased words of s, without stop words.
+func terms(s string) map[string]struct{} {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	terms := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		if _, ok := stopWords[w]; ok || len(w) < 2 {
+			continue
+		}
+		terms[w] = struct{}{}
+	}
+
+	return terms
+}
+
+// termIDs numbers the terms of every document, rarest first, so that the terms of
+// each document can be ordered the same way by their IDs.
+func termIDs(docs []map[string]struct{}) map[string]int {
+	frequencies := make(map[string]int)
+	for _, d := range docs {
+		for t := range d {
+			frequencies[t]++
+		}
+	}
+
+	terms := make([]string, 0, len(frequencies))
+	for t := range frequencies {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if frequencies[terms[i]] != frequencies[terms[j]] {
+			return frequencies[terms[i]] < frequencies[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+
+	ids := make(map[string]int, len(terms))
+	for i, t := range terms {
+		ids[t] = i
+	}
+	return ids
+}
+
+// sortedIDs returns the IDs of terms in ascending order.
+func sortedIDs(terms map[string]struct{}, ids map[string]int) []int {
+	sorted := make([]int, 0, len(terms))
+	for t := range terms {
+		sorted = append(sorted, ids[t])
+	}
+	sort.Ints(sorted)
+	return sorted
+}
+
+// jaccard returns the number of terms a and b have in common as a fraction of all
+// their terms, given their sorted term IDs.
+func jaccard(a, b []int) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	var common int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			common++
+			i++
+			j++
+		}
+	}
+
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+func newForest(docs []document) *forest {
+	f := &forest{
+		parents:   make([]int, len(docs)),
+		providers: make([]map[news.Provider]struct{}, len(docs)),
+	}
+	for i, d := range docs {
+		f.parents[i] = i
+		f.providers[i] = map[news.Provider]struct{}{d.provider: {}}
+	}
+	return f
+}
+
+func (f *forest) find(i int) int {
+	for f.parents[i] != i {
+		f.parents[i] = f.parents[f.parents[i]]
+		i = f.parents[i]
+	}
+	return i
+}
+
+// union joins the clusters of i and j, unless they have items from the same
+// provider. Similarity isn't transitive, so two stories from one provider could
+// otherwise be joined through an item from another provider that's like both.
+func (f *forest) union(i, j int) {
+	ri, rj := f.find(i), f.find(j)
+	if ri == rj {
+		return
+	}
+	for p := range f.providers[rj] {
+		if _, ok := f.providers[ri][p]; ok {
+			return
+		}
+	}
+
+	// the lower index is the root, so the root of a cluster is its first item
+	if rj < ri {
+		ri, rj = rj, ri
+	}
+	f.parents[rj] = ri
+	for p := range f.providers[rj] {
+		f.providers[ri][p] = struct{}{}
+	}
+	f.providers[rj] = nil
+}
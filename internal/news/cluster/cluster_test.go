@@ -0,0 +1,183 @@
+package cluster_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/news/cluster"
+)
+
+func TestNew_Error(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		opts                   []cluster.Option
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "threshold is invalid",
+			opts:                   []cluster.Option{cluster.WithThreshold(0)},
+			expectedErrorParameter: "threshold",
+		},
+		{
+			name:                   "threshold is too high",
+			opts:                   []cluster.Option{cluster.WithThreshold(1.5)},
+			expectedErrorParameter: "threshold",
+		},
+		{
+			name:                   "window is invalid",
+			opts:                   []cluster.Option{cluster.WithWindow(0)},
+			expectedErrorParameter: "window",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := cluster.New(tc.opts...)
+			require.Error(t, err)
+			require.Nil(t, c)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestClusterer_Cluster(t *testing.T) {
+	now := time.Now()
+
+	item := func(provider news.Provider, title, link string, age time.Duration) news.Item {
+		return news.Item{Provider: provider, Title: title, Link: link, DateTime: now.Add(-age)}
+	}
+
+	testCases := []struct {
+		name             string
+		items            []news.Item
+		expectedClusters [][]int
+	}{
+		{
+			name: "no similar items",
+			items: []news.Item{
+				item(news.ProviderBBC, "Storm Darcy brings snow to the south east", "https://bbc.co.uk/1", 0),
+				item(news.ProviderSky, "Chancellor sets out spending plans", "https://sky.com/1", 0),
+			},
+		},
+		{
+			name: "similar titles from different providers",
+			items: []news.Item{
+				item(news.ProviderBBC, "Storm Darcy brings heavy snow to south east England", "https://bbc.co.uk/1", 0),
+				item(news.ProviderSky, "Chancellor sets out spending plans", "https://sky.com/1", 0),
+				item(news.ProviderSky, "Heavy snow as Storm Darcy hits south east England", "https://sky.com/2", time.Hour),
+			},
+			expectedClusters: [][]int{{0, 2}},
+		},
+		{
+			name: "same canonical link",
+			items: []news.Item{
+				item(news.ProviderBBC, "Live: latest updates", "https://news.com/story?utm_source=bbc", 0),
+				item(news.ProviderSky, "Breaking news", "https://news.com/story/", 0),
+			},
+			expectedClusters: [][]int{{0, 1}},
+		},
+		{
+			name: "same canonical link without text",
+			items: []news.Item{
+				item(news.ProviderBBC, "", "https://news.com/story", 0),
+				item(news.ProviderSky, "", "https://news.com/story", 0),
+				item(news.ProviderSky, "", "https://news.com/other", 0),
+			},
+			expectedClusters: [][]int{{0, 1}},
+		},
+		{
+			name: "similar titles from the same provider",
+			items: []news.Item{
+				item(news.ProviderBBC, "Storm Darcy brings heavy snow to south east England", "https://bbc.co.uk/1", 0),
+				item(news.ProviderBBC, "Heavy snow as Storm Darcy hits south east England", "https://bbc.co.uk/2", 0),
+			},
+		},
+		{
+			name: "similar titles outside the window",
+			items: []news.Item{
+				item(news.ProviderBBC, "Storm Darcy brings heavy snow to south east England", "https://bbc.co.uk/1", 0),
+				item(news.ProviderSky, "Heavy snow as Storm Darcy hits south east England", "https://sky.com/1", 72*time.Hour),
+			},
+		},
+		{
+			name: "cluster of several providers",
+			items: []news.Item{
+				item(news.ProviderBBC, "Storm Darcy brings heavy snow to south east England", "https://bbc.co.uk/1", 0),
+				item(news.ProviderSky, "Heavy snow as Storm Darcy hits south east England", "https://sky.com/1", 0),
+				item("guardian", "Storm Darcy heavy snow south east England travel disruption", "https://guardian.com/1", 0),
+			},
+			expectedClusters: [][]int{{0, 1, 2}},
+		},
+		{
+			name: "stories from the same provider joined by another provider",
+			items: []news.Item{
+				item(news.ProviderBBC, "Storm Darcy brings snow to Kent", "https://bbc.co.uk/1", 0),
+				item(news.ProviderSky, "Storm Darcy brings snow to Kent and floods to Wales", "https://sky.com/1", 0),
+				item(news.ProviderBBC, "Storm Darcy floods hit Wales", "https://bbc.co.uk/2", 0),
+			},
+			expectedClusters: [][]int{{0, 1}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := cluster.New()
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedClusters, c.Cluster(tc.items))
+		})
+	}
+}
+
+func BenchmarkClusterer_Cluster(b *testing.B) {
+	now := time.Now()
+	r := rand.New(rand.NewSource(1))
+
+	words := make([]string, 5000)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+	text := func(n int) string {
+		w := make([]string, n)
+		for i := range w {
+			w[i] = words[r.Intn(len(words))]
+		}
+		return strings.Join(w, " ")
+	}
+
+	// a page of 1000 items with history from each of 4 providers, which often have the
+	// same stories
+	providers := []news.Provider{"bbc", "sky", "guardian", "reuters"}
+	var items []news.Item
+	for len(items) < 4000 {
+		title, description := text(8), text(20)
+		for _, p := range providers[:1+r.Intn(len(providers))] {
+			items = append(items, news.Item{
+				Provider:    p,
+				Title:       title,
+				Description: description,
+				Link:        fmt.Sprintf("https://%s.com/%d", p, len(items)),
+				DateTime:    now.Add(-time.Duration(r.Intn(72)) * time.Hour),
+			})
+		}
+	}
+
+	c, err := cluster.New()
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Cluster(items)
+	}
+}
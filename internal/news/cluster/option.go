@@ -0,0 +1,21 @@
+package cluster
+
+import "time"
+
+type Option func(*clusterer)
+
+// WithThreshold sets the similarity, between 0 and 1, above which items are about
+// the same story. Similarity is the fraction of the words in the titles and
+// descriptions of two items that they have in common.
+func WithThreshold(threshold float64) Option {
+	return func(c *clusterer) {
+		c.threshold = threshold
+	}
+}
+
+// WithWindow sets the max time between items about the same story.
+func WithWindow(window time.Duration) Option {
+	return func(c *clusterer) {
+		c.window = window
+	}
+}
@@ -0,0 +1,53 @@
+package news
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+// Cursor is the position of an item in a feed. Feeds are sorted newest first, and
+// then by ID, so a page that starts after a cursor continues from the same item
+// when items are added to or removed from the feed.
+type Cursor struct {
+	DateTime time.Time
+	ID       string
+}
+
+// NewCursor returns the position of item.
+func NewCursor(item Item) Cursor {
+	return Cursor{DateTime: item.DateTime, ID: item.ID()}
+}
+
+// ParseCursor decodes a cursor encoded by Cursor.String.
+func ParseCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(b), " ", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	dateTime, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{DateTime: dateTime, ID: parts[1]}, nil
+}
+
+// String encodes the cursor as an opaque string that's safe to use in a URL.
+func (c Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.DateTime.Format(time.RFC3339Nano) + " " + c.ID))
+}
+
+// Before reports whether item comes after the cursor in a feed.
+func (c Cursor) Before(item Item) bool {
+	if !item.DateTime.Equal(c.DateTime) {
+		return item.DateTime.Before(c.DateTime)
+	}
+	return item.ID() > c.ID
+}
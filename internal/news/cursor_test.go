@@ -0,0 +1,60 @@
+package news_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/news"
+)
+
+func TestParseCursor_Error(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cursor string
+	}{
+		{
+			name:   "not base64",
+			cursor: "not a cursor!",
+		},
+		{
+			name:   "missing id",
+			cursor: base64.RawURLEncoding.EncodeToString([]byte("2021-02-06T20:47:21Z")),
+		},
+		{
+			name:   "invalid date",
+			cursor: base64.RawURLEncoding.EncodeToString([]byte("yesterday 1234")),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := news.ParseCursor(tc.cursor)
+			assert.Equal(t, news.ErrInvalidCursor, err)
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	now := time.Date(2021, time.February, 6, 20, 47, 21, 123456789, time.UTC)
+
+	item := news.Item{Provider: news.ProviderBBC, GUID: "1", DateTime: now}
+	cursor, err := news.ParseCursor(news.NewCursor(item).String())
+	require.NoError(t, err)
+
+	assert.True(t, cursor.DateTime.Equal(now))
+	assert.Equal(t, item.ID(), cursor.ID)
+
+	older := news.Item{Provider: news.ProviderBBC, GUID: "2", DateTime: now.Add(-time.Second)}
+	newer := news.Item{Provider: news.ProviderBBC, GUID: "3", DateTime: now.Add(time.Second)}
+
+	assert.True(t, cursor.Before(older))
+	assert.False(t, cursor.Before(newer))
+	assert.False(t, cursor.Before(item))
+
+	same := news.Item{Provider: news.ProviderBBC, GUID: "4", DateTime: now}
+	assert.Equal(t, same.ID() > item.ID(), cursor.Before(same))
+}
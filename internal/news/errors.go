@@ -8,6 +8,10 @@ import (
 var (
 	ErrProviderNotFound = errors.New("provider not found")
 	ErrCategoryNotFound = errors.New("category not found")
+	// ErrProviderUnavailable is returned when a provider isn't being called, e.g. because its circuit breaker is open.
+	ErrProviderUnavailable = errors.New("provider unavailable")
+	// ErrInvalidCursor is returned when a cursor can't be decoded.
+	ErrInvalidCursor = errors.New("invalid cursor")
 )
 
 // InvalidParameterError is returned when a parameter is invalid.
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -14,10 +17,16 @@ import (
 	"github.com/cshep4/news-api/internal/news"
 )
 
+const (
+	// headerDegraded is set on responses missing the items of a provider or category.
+	headerDegraded = "X-Degraded"
+)
+
 type (
 	NewsService interface {
-		GetFeedByCategory(ctx context.Context, provider news.Provider, category news.Category, offset, limit int) (*news.FeedResponse, error)
-		GetFeed(ctx context.Context, provider news.Provider, offset, limit int) (*news.FeedResponse, error)
+		GetFeedByCategory(ctx context.Context, provider news.Provider, category news.Category, query news.Query) (*news.FeedResponse, error)
+		GetFeed(ctx context.Context, provider news.Provider, query news.Query) (*news.FeedResponse, error)
+		Search(ctx context.Context, provider news.Provider, category news.Category, query news.SearchQuery) (*news.FeedResponse, error)
 	}
 
 	handler struct {
@@ -42,6 +51,9 @@ func New(newsService NewsService) (*handler, error) {
 func (h *handler) Route(router *mux.Router) {
 	router.HandleFunc("/", h.getFeed).
 		Methods(http.MethodGet)
+	// registered before /{category} so that it isn't taken for a category
+	router.HandleFunc("/search", h.search).
+		Methods(http.MethodGet)
 	router.HandleFunc("/{category}", h.getFeedByCategory).
 		Methods(http.MethodGet)
 }
@@ -49,33 +61,30 @@ func (h *handler) Route(router *mux.Router) {
 func (h *handler) getFeed(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 
-	provider := news.Provider(r.URL.Query().Get("provider"))
-	if provider == "" {
-		provider = news.ProviderAll
-	}
-
-	limit, err := h.intParam(r.URL.Query(), "limit")
+	query, err := h.query(r.URL.Query())
 	if err != nil {
-		h.errorResponse(r.Context(), http.StatusBadRequest, "limit is invalid", w)
+		h.errorResponse(r.Context(), http.StatusBadRequest, err.Error(), w)
 		return
 	}
 
-	offset, err := h.intParam(r.URL.Query(), "offset")
-	if err != nil {
-		h.errorResponse(r.Context(), http.StatusBadRequest, "offset is invalid", w)
-		return
-	}
+	var provider news.Provider
+	provider, query.Providers = h.providerParam(r.URL.Query())
+	query.Categories = h.categoriesParam(r.URL.Query(), "category")
 
-	res, err := h.newsService.GetFeed(r.Context(), provider, offset, limit)
+	res, err := h.newsService.GetFeed(r.Context(), provider, query)
 	if err != nil {
 		log.Error(r.Context(), "error_getting_feed",
 			log.SafeParam("provider", provider),
-			log.SafeParam("limit", limit),
-			log.SafeParam("offset", offset),
+			log.SafeParam("providers", query.Providers),
+			log.SafeParam("categories", query.Categories),
+			log.SafeParam("limit", query.Limit),
+			log.SafeParam("offset", query.Offset),
+			log.SafeParam("history", query.History),
+			log.SafeParam("cluster", query.Cluster),
 			log.ErrorParam(err),
 		)
 	}
-	h.sendResponse(r.Context(), w, res, err)
+	h.sendFeedResponse(w, r, res, err)
 }
 
 func (h *handler) getFeedByCategory(w http.ResponseWriter, r *http.Request) {
@@ -87,34 +96,232 @@ func (h *handler) getFeedByCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	limit, err := h.intParam(r.URL.Query(), "limit")
+	query, err := h.query(r.URL.Query())
 	if err != nil {
-		h.errorResponse(r.Context(), http.StatusBadRequest, "limit is invalid", w)
+		h.errorResponse(r.Context(), http.StatusBadRequest, err.Error(), w)
 		return
 	}
 
-	offset, err := h.intParam(r.URL.Query(), "offset")
+	var provider news.Provider
+	provider, query.Providers = h.providerParam(r.URL.Query())
+
+	res, err := h.newsService.GetFeedByCategory(r.Context(), provider, news.Category(category), query)
 	if err != nil {
-		h.errorResponse(r.Context(), http.StatusBadRequest, "offset is invalid", w)
+		log.Error(r.Context(), "error_getting_feed_category",
+			log.SafeParam("category", category),
+			log.SafeParam("provider", provider),
+			log.SafeParam("providers", query.Providers),
+			log.SafeParam("limit", query.Limit),
+			log.SafeParam("offset", query.Offset),
+			log.SafeParam("history", query.History),
+			log.SafeParam("cluster", query.Cluster),
+			log.ErrorParam(err),
+		)
+	}
+	h.sendFeedResponse(w, r, res, err)
+}
+
+func (h *handler) search(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	query, err := h.searchQuery(r.URL.Query())
+	if err != nil {
+		h.errorResponse(r.Context(), http.StatusBadRequest, err.Error(), w)
 		return
 	}
 
-	provider := news.Provider(r.URL.Query().Get("provider"))
-	if provider == "" {
-		provider = news.ProviderAll
+	var (
+		provider   news.Provider
+		category   news.Category
+		categories = h.categoriesParam(r.URL.Query(), "category")
+	)
+	provider, query.Providers = h.providerParam(r.URL.Query())
+	if len(categories) == 1 {
+		category = categories[0]
+	} else {
+		query.Categories = categories
 	}
 
-	res, err := h.newsService.GetFeedByCategory(r.Context(), provider, news.Category(category), offset, limit)
+	res, err := h.newsService.Search(r.Context(), provider, category, query)
 	if err != nil {
-		log.Error(r.Context(), "error_getting_feed_category",
+		log.Error(r.Context(), "error_searching_feed",
 			log.SafeParam("category", category),
+			log.SafeParam("categories", query.Categories),
 			log.SafeParam("provider", provider),
-			log.SafeParam("limit", limit),
-			log.SafeParam("offset", offset),
+			log.SafeParam("providers", query.Providers),
+			log.SafeParam("limit", query.Limit),
+			log.SafeParam("offset", query.Offset),
 			log.ErrorParam(err),
 		)
 	}
-	h.sendResponse(r.Context(), w, res, err)
+	h.sendFeedResponse(w, r, res, err)
+}
+
+// searchQuery parses the query params of a search.
+func (h *handler) searchQuery(values url.Values) (news.SearchQuery, error) {
+	text := strings.TrimSpace(values.Get("q"))
+	if text == "" {
+		return news.SearchQuery{}, errors.New("q is required")
+	}
+
+	query, err := h.query(values)
+	if err != nil {
+		return news.SearchQuery{}, err
+	}
+	if query.Cursor != nil {
+		return news.SearchQuery{}, errors.New("cursor is not supported by search")
+	}
+
+	return news.SearchQuery{
+		Query: query,
+		Text:  text,
+	}, nil
+}
+
+// query parses the query params that select the items of a feed.
+func (h *handler) query(values url.Values) (news.Query, error) {
+	limit, err := h.intParam(values, "limit")
+	if err != nil || limit < 0 {
+		return news.Query{}, errors.New("limit is invalid")
+	}
+
+	offset, err := h.intParam(values, "offset")
+	if err != nil || offset < 0 {
+		return news.Query{}, errors.New("offset is invalid")
+	}
+
+	history, err := h.boolParam(values, "history")
+	if err != nil {
+		return news.Query{}, errors.New("history is invalid")
+	}
+	switch {
+	case history && limit <= 0:
+		return news.Query{}, errors.New("limit is required with history")
+	case history && offset+limit > news.MaxHistoryItems:
+		return news.Query{}, fmt.Errorf("offset and limit must add up to at most %d with history, use cursor to page further", news.MaxHistoryItems)
+	}
+
+	var cursor *news.Cursor
+	if param := values.Get("cursor"); param != "" {
+		c, err := news.ParseCursor(param)
+		if err != nil {
+			return news.Query{}, errors.New("cursor is invalid")
+		}
+		cursor = &c
+	}
+
+	cluster := news.ClusterMode(values.Get("cluster"))
+	switch cluster {
+	case news.ClusterModeNone, news.ClusterModeCollapse, news.ClusterModeAnnotate:
+	default:
+		return news.Query{}, errors.New("cluster is invalid")
+	}
+
+	since, err := h.timeParam(values, "since")
+	if err != nil {
+		return news.Query{}, errors.New("since is invalid, must be an RFC 3339 timestamp, e.g. 2021-02-06T20:47:21Z")
+	}
+
+	until, err := h.timeParam(values, "until")
+	if err != nil {
+		return news.Query{}, errors.New("until is invalid, must be an RFC 3339 timestamp, e.g. 2021-02-06T20:47:21Z")
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return news.Query{}, errors.New("until is before since")
+	}
+
+	maxAge, err := h.durationParam(values, "maxAge")
+	if err != nil || maxAge < 0 {
+		return news.Query{}, errors.New("maxAge is invalid, must be a duration that is not negative, e.g. 6h")
+	}
+
+	return news.Query{
+		Cursor:            cursor,
+		Offset:            offset,
+		Limit:             limit,
+		History:           history,
+		Cluster:           cluster,
+		Since:             since,
+		Until:             until,
+		MaxAge:            maxAge,
+		ExcludeProviders:  h.providersParam(values, "excludeProvider"),
+		ExcludeCategories: h.categoriesParam(values, "excludeCategory"),
+	}, nil
+}
+
+// providerParam returns the provider param if it names one provider, or ProviderAll
+// along with the providers if it names several.
+func (h *handler) providerParam(values url.Values) (news.Provider, []news.Provider) {
+	providers := h.providersParam(values, "provider")
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return news.ProviderAll, providers
+}
+
+func (h *handler) providersParam(values url.Values, key string) []news.Provider {
+	var providers []news.Provider
+	for _, p := range h.listParam(values, key) {
+		providers = append(providers, news.Provider(p))
+	}
+	return providers
+}
+
+func (h *handler) categoriesParam(values url.Values, key string) []news.Category {
+	var categories []news.Category
+	for _, c := range h.listParam(values, key) {
+		categories = append(categories, news.Category(c))
+	}
+	return categories
+}
+
+// listParam returns the distinct values of a param that's repeated or given as a
+// comma separated list, e.g. provider=bbc,sky or provider=bbc&provider=sky.
+func (h *handler) listParam(values url.Values, key string) []string {
+	var (
+		list []string
+		seen = make(map[string]struct{})
+	)
+	for _, param := range values[key] {
+		for _, v := range strings.Split(param, ",") {
+			v = strings.TrimSpace(v)
+			if _, ok := seen[v]; ok || v == "" {
+				continue
+			}
+			seen[v] = struct{}{}
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func (h *handler) boolParam(values url.Values, key string) (bool, error) {
+	param := values.Get(key)
+	if param == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(param)
+}
+
+// timeParam parses an RFC 3339 timestamp, e.g. 2021-02-06T20:47:21Z.
+func (h *handler) timeParam(values url.Values, key string) (time.Time, error) {
+	param := values.Get(key)
+	if param == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, param)
+}
+
+// durationParam parses a duration, e.g. 6h or 90m.
+func (h *handler) durationParam(values url.Values, key string) (time.Duration, error) {
+	param := values.Get(key)
+	if param == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(param)
 }
 
 func (h *handler) intParam(values url.Values, key string) (int, error) {
@@ -131,6 +338,84 @@ func (h *handler) intParam(values url.Values, key string) (int, error) {
 	return n, nil
 }
 
+// sendFeedResponse sends res, flagging it with the degraded header if the items
+// of any source are missing, and linking to the next and previous pages.
+func (h *handler) sendFeedResponse(w http.ResponseWriter, r *http.Request, res *news.FeedResponse, err error) {
+	if err == nil {
+		if res.Degraded {
+			w.Header().Set(headerDegraded, "true")
+		}
+		h.setLinks(w, r, res)
+	}
+	h.sendResponse(r.Context(), w, res, err)
+}
+
+// setLinks sets the absolute URLs of the next and previous pages of res, and adds
+// them as RFC 8288 Link headers. The next page is linked by cursor when there is one,
+// unless the request pages by offset. Cursors only page forwards, so a page reached
+// by cursor doesn't link to a previous page.
+func (h *handler) setLinks(w http.ResponseWriter, r *http.Request, res *news.FeedResponse) {
+	values := r.URL.Query()
+	byCursor := values.Get("cursor") != ""
+
+	if res.HasMore {
+		next := copyValues(values)
+		if res.NextCursor != "" && (byCursor || res.Offset == 0) {
+			next.Set("cursor", res.NextCursor)
+			next.Del("offset")
+		} else {
+			next.Set("offset", strconv.Itoa(res.Offset+res.Limit))
+		}
+		res.Next = absoluteURL(r, next)
+	}
+
+	if !byCursor && res.Offset > 0 && res.Limit > 0 {
+		prev := copyValues(values)
+		offset := res.Offset - res.Limit
+		if offset > 0 {
+			prev.Set("offset", strconv.Itoa(offset))
+		} else {
+			prev.Del("offset")
+		}
+		res.Prev = absoluteURL(r, prev)
+	}
+
+	if res.Next != "" {
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, res.Next))
+	}
+	if res.Prev != "" {
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="prev"`, res.Prev))
+	}
+}
+
+// absoluteURL returns the URL of the request's path with values as the query. The
+// scheme is taken from the X-Forwarded-Proto header when the service is behind a proxy.
+func absoluteURL(r *http.Request, values url.Values) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     r.Host,
+		Path:     r.URL.Path,
+		RawQuery: values.Encode(),
+	}
+	return u.String()
+}
+
+func copyValues(values url.Values) url.Values {
+	c := make(url.Values, len(values))
+	for k, v := range values {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
 func (h *handler) sendResponse(ctx context.Context, w http.ResponseWriter, res interface{}, err error) {
 	switch {
 	case err == nil:
@@ -13,3 +13,7 @@ func (h *handler) GetFeed(w http.ResponseWriter, r *http.Request) {
 func (h *handler) GetFeedByCategory(w http.ResponseWriter, r *http.Request) {
 	h.getFeedByCategory(w, r)
 }
+
+func (h *handler) Search(w http.ResponseWriter, r *http.Request) {
+	h.search(w, r)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -83,12 +84,72 @@ func TestHandler_GetFeed_Error(t *testing.T) {
 			expectedStatusCode: http.StatusBadRequest,
 			expectedError:      "limit is invalid",
 		},
+		{
+			name:               "negative limit",
+			path:               "/?limit=-1",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "limit is invalid",
+		},
 		{
 			name:               "invalid offset",
 			path:               "/?offset=offset",
 			expectedStatusCode: http.StatusBadRequest,
 			expectedError:      "offset is invalid",
 		},
+		{
+			name:               "negative offset with history",
+			path:               "/?history=true&offset=-1000000&limit=10",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "offset is invalid",
+		},
+		{
+			name:               "invalid history",
+			path:               "/?history=sometimes",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "history is invalid",
+		},
+		{
+			name:               "history without limit",
+			path:               "/?history=true",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "limit is required with history",
+		},
+		{
+			name:               "history past max items",
+			path:               "/?history=true&offset=990&limit=20",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "offset and limit must add up to at most 1000 with history, use cursor to page further",
+		},
+		{
+			name:               "invalid cluster",
+			path:               "/?cluster=merge",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "cluster is invalid",
+		},
+		{
+			name:               "invalid cursor",
+			path:               "/?cursor=cursor",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "cursor is invalid",
+		},
+		{
+			name:               "invalid since",
+			path:               "/?since=yesterday",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "since is invalid, must be an RFC 3339 timestamp, e.g. 2021-02-06T20:47:21Z",
+		},
+		{
+			name:               "until before since",
+			path:               "/?since=2021-02-07T00:00:00Z&until=2021-02-06T00:00:00Z",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "until is before since",
+		},
+		{
+			name:               "invalid max age",
+			path:               "/?maxAge=1d",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "maxAge is invalid, must be a duration that is not negative, e.g. 6h",
+		},
 		{
 			name:               "category not found",
 			path:               "/",
@@ -123,7 +184,7 @@ func TestHandler_GetFeed_Error(t *testing.T) {
 			rr := httptest.NewRecorder()
 
 			if tc.testErr != nil {
-				service.EXPECT().GetFeed(req.Context(), tc.provider, 0, 0).Return(nil, tc.testErr)
+				service.EXPECT().GetFeed(req.Context(), tc.provider, news.Query{}).Return(nil, tc.testErr)
 			}
 
 			h, err := handler.New(service)
@@ -147,18 +208,40 @@ func TestHandler_GetFeed_Success(t *testing.T) {
 		provider         news.Provider
 		limit            int
 		offset           int
+		history          bool
+		cluster          news.ClusterMode
+		cursor           *news.Cursor
 		expectedResponse news.FeedResponse
+		expectedDegraded string
 	}{
 		{
 			name:     "returns feed response",
 			limit:    1,
 			offset:   2,
+			history:  true,
+			cluster:  news.ClusterModeCollapse,
+			cursor:   &news.Cursor{DateTime: time.Date(2021, time.February, 6, 20, 47, 21, 0, time.UTC), ID: "1234"},
 			provider: news.ProviderBBC,
 			expectedResponse: news.FeedResponse{
 				Provider: news.ProviderBBC,
 				Limit:    1,
 				Offset:   2,
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+				},
+			},
+		},
+		{
+			name:     "returns degraded feed response",
+			provider: news.ProviderAll,
+			expectedResponse: news.FeedResponse{
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusFailed, Error: news.ErrorClassTimeout},
+				},
+				Degraded: true,
 			},
+			expectedDegraded: "true",
 		},
 	}
 
@@ -169,10 +252,15 @@ func TestHandler_GetFeed_Success(t *testing.T) {
 
 			service := service_mock.NewMockNewsService(ctrl)
 
-			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/?limit=%d&offset=%d&provider=%s", tc.limit, tc.offset, tc.provider), nil)
+			var cursor string
+			if tc.cursor != nil {
+				cursor = tc.cursor.String()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/?limit=%d&offset=%d&history=%t&cluster=%s&cursor=%s&provider=%s", tc.limit, tc.offset, tc.history, tc.cluster, cursor, tc.provider), nil)
 			rr := httptest.NewRecorder()
 
-			service.EXPECT().GetFeed(req.Context(), tc.provider, tc.offset, tc.limit).Return(&tc.expectedResponse, nil)
+			service.EXPECT().GetFeed(req.Context(), tc.provider, news.Query{Offset: tc.offset, Limit: tc.limit, History: tc.history, Cluster: tc.cluster, Cursor: tc.cursor}).Return(&tc.expectedResponse, nil)
 
 			h, err := handler.New(service)
 			require.NoError(t, err)
@@ -184,11 +272,102 @@ func TestHandler_GetFeed_Success(t *testing.T) {
 			require.NoError(t, json.NewDecoder(rr.Result().Body).Decode(&responseBody))
 
 			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tc.expectedDegraded, rr.Header().Get("X-Degraded"))
 			assert.Equal(t, tc.expectedResponse, responseBody)
 		})
 	}
 }
 
+func TestHandler_GetFeed_DateRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		service = service_mock.NewMockNewsService(ctrl)
+		since   = time.Date(2021, time.February, 6, 0, 0, 0, 0, time.UTC)
+		until   = time.Date(2021, time.February, 7, 0, 0, 0, 0, time.UTC)
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/?since=2021-02-06T00:00:00Z&until=2021-02-07T00:00:00Z&maxAge=6h", nil)
+	rr := httptest.NewRecorder()
+
+	service.EXPECT().GetFeed(req.Context(), news.ProviderAll, news.Query{
+		Since:  since,
+		Until:  until,
+		MaxAge: 6 * time.Hour,
+	}).Return(&news.FeedResponse{}, nil)
+
+	h, err := handler.New(service)
+	require.NoError(t, err)
+
+	h.GetFeed(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestHandler_GetFeed_Selection(t *testing.T) {
+	testCases := []struct {
+		name             string
+		path             string
+		expectedProvider news.Provider
+		expectedQuery    news.Query
+	}{
+		{
+			name:             "one provider",
+			path:             "/?provider=bbc",
+			expectedProvider: news.ProviderBBC,
+		},
+		{
+			name:             "comma separated",
+			path:             "/?provider=bbc,sky&category=uk,technology",
+			expectedProvider: news.ProviderAll,
+			expectedQuery: news.Query{
+				Providers:  []news.Provider{news.ProviderBBC, news.ProviderSky},
+				Categories: []news.Category{news.CategoryUK, news.CategoryTechnology},
+			},
+		},
+		{
+			name:             "repeated",
+			path:             "/?provider=bbc&provider=sky,%20bbc&category=uk",
+			expectedProvider: news.ProviderAll,
+			expectedQuery: news.Query{
+				Providers:  []news.Provider{news.ProviderBBC, news.ProviderSky},
+				Categories: []news.Category{news.CategoryUK},
+			},
+		},
+		{
+			name:             "excluded",
+			path:             "/?excludeProvider=sky&excludeCategory=uk,technology",
+			expectedProvider: news.ProviderAll,
+			expectedQuery: news.Query{
+				ExcludeProviders:  []news.Provider{news.ProviderSky},
+				ExcludeCategories: []news.Category{news.CategoryUK, news.CategoryTechnology},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service_mock.NewMockNewsService(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+
+			service.EXPECT().GetFeed(req.Context(), tc.expectedProvider, tc.expectedQuery).Return(&news.FeedResponse{}, nil)
+
+			h, err := handler.New(service)
+			require.NoError(t, err)
+
+			h.GetFeed(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
 func TestHandler_GetFeedByCategory_Error(t *testing.T) {
 	testCases := []struct {
 		name               string
@@ -219,6 +398,55 @@ func TestHandler_GetFeedByCategory_Error(t *testing.T) {
 			expectedStatusCode: http.StatusBadRequest,
 			expectedError:      "offset is invalid",
 		},
+		{
+			name:               "negative offset",
+			path:               "/%s?offset=-5",
+			category:           news.CategoryUK,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "offset is invalid",
+		},
+		{
+			name:               "invalid history",
+			path:               "/%s?history=sometimes",
+			category:           news.CategoryUK,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "history is invalid",
+		},
+		{
+			name:               "history without limit",
+			path:               "/%s?history=true",
+			category:           news.CategoryUK,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "limit is required with history",
+		},
+		{
+			name:               "invalid cluster",
+			path:               "/%s?cluster=merge",
+			category:           news.CategoryUK,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "cluster is invalid",
+		},
+		{
+			name:               "invalid cursor",
+			path:               "/%s?cursor=cursor",
+			category:           news.CategoryUK,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "cursor is invalid",
+		},
+		{
+			name:               "invalid until",
+			path:               "/%s?until=2021-02-06",
+			category:           news.CategoryUK,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "until is invalid, must be an RFC 3339 timestamp, e.g. 2021-02-06T20:47:21Z",
+		},
+		{
+			name:               "negative max age",
+			path:               "/%s?maxAge=-1h",
+			category:           news.CategoryUK,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "maxAge is invalid, must be a duration that is not negative, e.g. 6h",
+		},
 		{
 			name:               "category not found",
 			path:               "/%s",
@@ -262,7 +490,7 @@ func TestHandler_GetFeedByCategory_Error(t *testing.T) {
 			rr := httptest.NewRecorder()
 
 			if tc.testErr != nil {
-				service.EXPECT().GetFeedByCategory(req.Context(), tc.provider, tc.category, 0, 0).Return(nil, tc.testErr)
+				service.EXPECT().GetFeedByCategory(req.Context(), tc.provider, tc.category, news.Query{}).Return(nil, tc.testErr)
 			}
 
 			h, err := handler.New(service)
@@ -287,18 +515,40 @@ func TestHandler_GetFeedByCategory_Success(t *testing.T) {
 		category         news.Category
 		limit            int
 		offset           int
+		history          bool
+		cluster          news.ClusterMode
+		cursor           *news.Cursor
 		expectedResponse news.FeedResponse
+		expectedDegraded string
 	}{
 		{
 			name:     "returns feed response",
 			limit:    1,
 			offset:   2,
+			history:  true,
+			cluster:  news.ClusterModeCollapse,
+			cursor:   &news.Cursor{DateTime: time.Date(2021, time.February, 6, 20, 47, 21, 0, time.UTC), ID: "1234"},
 			provider: news.ProviderBBC,
 			expectedResponse: news.FeedResponse{
 				Provider: news.ProviderBBC,
 				Limit:    1,
 				Offset:   2,
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+				},
+			},
+		},
+		{
+			name:     "returns degraded feed response",
+			provider: news.ProviderAll,
+			expectedResponse: news.FeedResponse{
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusFailed, Error: news.ErrorClassTimeout},
+				},
+				Degraded: true,
 			},
+			expectedDegraded: "true",
 		},
 	}
 
@@ -309,14 +559,19 @@ func TestHandler_GetFeedByCategory_Success(t *testing.T) {
 
 			service := service_mock.NewMockNewsService(ctrl)
 
-			path := fmt.Sprintf("/%s?limit=%d&offset=%d&provider=%s", tc.category, tc.limit, tc.offset, tc.provider)
+			var cursor string
+			if tc.cursor != nil {
+				cursor = tc.cursor.String()
+			}
+
+			path := fmt.Sprintf("/%s?limit=%d&offset=%d&history=%t&cluster=%s&cursor=%s&provider=%s", tc.category, tc.limit, tc.offset, tc.history, tc.cluster, cursor, tc.provider)
 			req := httptest.NewRequest(http.MethodGet, path, nil)
 			req = mux.SetURLVars(req, map[string]string{
 				"category": string(tc.category),
 			})
 			rr := httptest.NewRecorder()
 
-			service.EXPECT().GetFeedByCategory(req.Context(), tc.provider, tc.category, tc.offset, tc.limit).Return(&tc.expectedResponse, nil)
+			service.EXPECT().GetFeedByCategory(req.Context(), tc.provider, tc.category, news.Query{Offset: tc.offset, Limit: tc.limit, History: tc.history, Cluster: tc.cluster, Cursor: tc.cursor}).Return(&tc.expectedResponse, nil)
 
 			h, err := handler.New(service)
 			require.NoError(t, err)
@@ -328,7 +583,256 @@ func TestHandler_GetFeedByCategory_Success(t *testing.T) {
 			require.NoError(t, json.NewDecoder(rr.Result().Body).Decode(&responseBody))
 
 			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tc.expectedDegraded, rr.Header().Get("X-Degraded"))
 			assert.Equal(t, tc.expectedResponse, responseBody)
 		})
 	}
 }
+
+func TestHandler_Search_Error(t *testing.T) {
+	testCases := []struct {
+		name               string
+		path               string
+		testErr            error
+		expectedStatusCode int
+		expectedError      string
+	}{
+		{
+			name:               "missing q",
+			path:               "/search?q=%20",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "q is required",
+		},
+		{
+			name:               "invalid limit",
+			path:               "/search?q=floods&limit=limit",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "limit is invalid",
+		},
+		{
+			name:               "cursor",
+			path:               "/search?q=floods&cursor=" + news.NewCursor(news.Item{GUID: "1"}).String(),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "cursor is not supported by search",
+		},
+		{
+			name:               "invalid since",
+			path:               "/search?q=floods&since=yesterday",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "since is invalid, must be an RFC 3339 timestamp, e.g. 2021-02-06T20:47:21Z",
+		},
+		{
+			name:               "invalid until",
+			path:               "/search?q=floods&until=2021-02-06",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedError:      "until is invalid, must be an RFC 3339 timestamp, e.g. 2021-02-06T20:47:21Z",
+		},
+		{
+			name:               "category not found",
+			path:               "/search?q=floods",
+			testErr:            news.ErrCategoryNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedError:      news.ErrCategoryNotFound.Error(),
+		},
+		{
+			name:               "internal error",
+			path:               "/search?q=floods",
+			testErr:            testError("error"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedError:      "could not get news feed",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service_mock.NewMockNewsService(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+
+			if tc.testErr != nil {
+				service.EXPECT().Search(req.Context(), news.ProviderAll, news.Category(""), news.SearchQuery{Text: "floods"}).Return(nil, tc.testErr)
+			}
+
+			h, err := handler.New(service)
+			require.NoError(t, err)
+			require.NotNil(t, h)
+
+			h.Search(rr, req)
+
+			var responseBody handler.ServerError
+			require.NoError(t, json.NewDecoder(rr.Result().Body).Decode(&responseBody))
+
+			assert.Equal(t, tc.expectedStatusCode, rr.Code)
+			assert.Equal(t, tc.expectedError, responseBody.Message)
+		})
+	}
+}
+
+func TestHandler_Search_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := service_mock.NewMockNewsService(ctrl)
+
+	since := time.Date(2021, time.February, 6, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+
+	expectedResponse := news.FeedResponse{
+		Category: news.CategoryUK,
+		Provider: news.ProviderBBC,
+		Items: []news.Item{
+			{
+				Categories: []news.Category{news.CategoryUK},
+				Provider:   news.ProviderBBC,
+				Title:      "Floods hit northern England",
+				Score:      1.5,
+				Highlight:  &news.Highlight{Title: "<em>Floods</em> hit northern England"},
+			},
+		},
+		Limit: 10,
+		Sources: []news.Source{
+			{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=floods+england&provider=bbc&category=uk&limit=10&since=2021-02-06T00:00:00Z&until=2021-02-07T00:00:00Z", nil)
+	rr := httptest.NewRecorder()
+
+	service.EXPECT().Search(gomock.Any(), news.ProviderBBC, news.CategoryUK, news.SearchQuery{
+		Query: news.Query{Limit: 10, Since: since, Until: until},
+		Text:  "floods england",
+	}).Return(&expectedResponse, nil)
+
+	h, err := handler.New(service)
+	require.NoError(t, err)
+
+	// the search route is matched rather than being taken for a category
+	router := mux.NewRouter()
+	h.Route(router)
+	router.ServeHTTP(rr, req)
+
+	var responseBody news.FeedResponse
+	require.NoError(t, json.NewDecoder(rr.Result().Body).Decode(&responseBody))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, expectedResponse, responseBody)
+}
+
+func TestHandler_Search_Selection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := service_mock.NewMockNewsService(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=floods&provider=bbc,sky&category=uk,technology&excludeProvider=sky", nil)
+	rr := httptest.NewRecorder()
+
+	service.EXPECT().Search(gomock.Any(), news.ProviderAll, news.Category(""), news.SearchQuery{
+		Query: news.Query{
+			Providers:        []news.Provider{news.ProviderBBC, news.ProviderSky},
+			Categories:       []news.Category{news.CategoryUK, news.CategoryTechnology},
+			ExcludeProviders: []news.Provider{news.ProviderSky},
+		},
+		Text: "floods",
+	}).Return(&news.FeedResponse{}, nil)
+
+	h, err := handler.New(service)
+	require.NoError(t, err)
+
+	h.Search(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestHandler_GetFeed_Links(t *testing.T) {
+	testCases := []struct {
+		name         string
+		path         string
+		response     news.FeedResponse
+		expectedNext string
+		expectedPrev string
+		expectedLink []string
+	}{
+		{
+			name:     "single page",
+			path:     "/",
+			response: news.FeedResponse{Total: total(2)},
+		},
+		{
+			name:         "history page without a total",
+			path:         "/?limit=2&history=true",
+			response:     news.FeedResponse{Limit: 2, HasMore: true, NextCursor: "abc"},
+			expectedNext: "http://example.com/?cursor=abc&history=true&limit=2",
+			expectedLink: []string{`<http://example.com/?cursor=abc&history=true&limit=2>; rel="next"`},
+		},
+		{
+			name:         "first page continues by cursor",
+			path:         "/?limit=2",
+			response:     news.FeedResponse{Limit: 2, Total: total(5), HasMore: true, NextCursor: "abc"},
+			expectedNext: "http://example.com/?cursor=abc&limit=2",
+			expectedLink: []string{`<http://example.com/?cursor=abc&limit=2>; rel="next"`},
+		},
+		{
+			name:         "cursor page",
+			path:         "/?limit=2&cursor=" + news.NewCursor(news.Item{GUID: "1"}).String(),
+			response:     news.FeedResponse{Limit: 2, Total: total(5), HasMore: true, NextCursor: "def"},
+			expectedNext: "http://example.com/?cursor=def&limit=2",
+			expectedLink: []string{`<http://example.com/?cursor=def&limit=2>; rel="next"`},
+		},
+		{
+			name:         "offset page",
+			path:         "/?provider=bbc&limit=2&offset=2",
+			response:     news.FeedResponse{Limit: 2, Offset: 2, Total: total(5), HasMore: true, NextCursor: "abc"},
+			expectedNext: "http://example.com/?limit=2&offset=4&provider=bbc",
+			expectedPrev: "http://example.com/?limit=2&provider=bbc",
+			expectedLink: []string{
+				`<http://example.com/?limit=2&offset=4&provider=bbc>; rel="next"`,
+				`<http://example.com/?limit=2&provider=bbc>; rel="prev"`,
+			},
+		},
+		{
+			name:         "last offset page",
+			path:         "/?limit=2&offset=3",
+			response:     news.FeedResponse{Limit: 2, Offset: 3, Total: total(5)},
+			expectedPrev: "http://example.com/?limit=2&offset=1",
+			expectedLink: []string{`<http://example.com/?limit=2&offset=1>; rel="prev"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := service_mock.NewMockNewsService(ctrl)
+			service.EXPECT().GetFeed(gomock.Any(), news.ProviderAll, gomock.Any()).Return(&tc.response, nil).MaxTimes(1)
+			service.EXPECT().GetFeed(gomock.Any(), news.ProviderBBC, gomock.Any()).Return(&tc.response, nil).MaxTimes(1)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com"+tc.path, nil)
+			rr := httptest.NewRecorder()
+
+			h, err := handler.New(service)
+			require.NoError(t, err)
+
+			h.GetFeed(rr, req)
+
+			var responseBody news.FeedResponse
+			require.NoError(t, json.NewDecoder(rr.Result().Body).Decode(&responseBody))
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tc.response.Total, responseBody.Total)
+			assert.Equal(t, tc.response.HasMore, responseBody.HasMore)
+			assert.Equal(t, tc.expectedNext, responseBody.Next)
+			assert.Equal(t, tc.expectedPrev, responseBody.Prev)
+			assert.Equal(t, tc.expectedLink, rr.Header().Values("Link"))
+		})
+	}
+}
+
+func total(n int) *int {
+	return &n
+}
@@ -0,0 +1,77 @@
+package news
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParams are query parameters that don't change the content a link points to.
+var trackingParams = []string{"utm_", "at_", "fbclid", "gclid", "ocid"}
+
+// ID returns an identifier for the item that's stable across fetches, derived from
+// its provider and its GUID, or its canonical link if it doesn't have one.
+func (i Item) ID() string {
+	key := i.GUID
+	if key == "" {
+		key = CanonicalLink(i.Link)
+	}
+	if key == "" {
+		key = i.Title
+	}
+
+	h := sha256.Sum256([]byte(string(i.Provider) + "\x00" + key))
+
+	return hex.EncodeToString(h[:16])
+}
+
+// CanonicalLink normalises link so that links to the same article compare equal: the
+// scheme and host are lower cased, the fragment, tracking parameters and trailing
+// slash are removed, and the remaining query parameters are sorted. A link that
+// can't be parsed is returned unchanged.
+func CanonicalLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return link
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	query := u.Query()
+	for k := range query {
+		if isTrackingParam(k) {
+			query.Del(k)
+		}
+	}
+
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	params := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			params = append(params, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	u.RawQuery = strings.Join(params, "&")
+
+	return u.String()
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	for _, p := range trackingParams {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package news_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cshep4/news-api/internal/news"
+)
+
+func TestItem_ID(t *testing.T) {
+	testCases := []struct {
+		name          string
+		item          news.Item
+		other         news.Item
+		expectedEqual bool
+	}{
+		{
+			name:          "same guid",
+			item:          news.Item{Provider: news.ProviderBBC, GUID: "1", Title: "title"},
+			other:         news.Item{Provider: news.ProviderBBC, GUID: "1", Title: "updated title"},
+			expectedEqual: true,
+		},
+		{
+			name:          "same guid from different providers",
+			item:          news.Item{Provider: news.ProviderBBC, GUID: "1"},
+			other:         news.Item{Provider: news.ProviderSky, GUID: "1"},
+			expectedEqual: false,
+		},
+		{
+			name:          "same canonical link",
+			item:          news.Item{Provider: news.ProviderBBC, Link: "https://bbc.co.uk/news/1?at_medium=RSS"},
+			other:         news.Item{Provider: news.ProviderBBC, Link: "https://BBC.co.uk/news/1/"},
+			expectedEqual: true,
+		},
+		{
+			name:          "different links",
+			item:          news.Item{Provider: news.ProviderBBC, Link: "https://bbc.co.uk/news/1"},
+			other:         news.Item{Provider: news.ProviderBBC, Link: "https://bbc.co.uk/news/2"},
+			expectedEqual: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedEqual, tc.item.ID() == tc.other.ID())
+		})
+	}
+}
+
+func TestCanonicalLink(t *testing.T) {
+	testCases := []struct {
+		name     string
+		link     string
+		expected string
+	}{
+		{
+			name:     "already canonical",
+			link:     "https://www.bbc.co.uk/news/uk-1",
+			expected: "https://www.bbc.co.uk/news/uk-1",
+		},
+		{
+			name:     "case, fragment and trailing slash",
+			link:     "HTTPS://WWW.BBC.CO.UK/news/uk-1/#comments",
+			expected: "https://www.bbc.co.uk/news/uk-1",
+		},
+		{
+			name:     "tracking params removed and params sorted",
+			link:     "https://news.sky.com/story?utm_source=rss&id=2&at_medium=RSS&b=1",
+			expected: "https://news.sky.com/story?b=1&id=2",
+		},
+		{
+			name:     "not a url",
+			link:     "not a url",
+			expected: "not a url",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, news.CanonicalLink(tc.link))
+		})
+	}
+}
@@ -9,11 +9,38 @@ const (
 
 	CategoryUK         Category = "uk"
 	CategoryTechnology Category = "technology"
+
+	MediaKindThumbnail MediaKind = "thumbnail"
+	MediaKindContent   MediaKind = "content"
+
+	SourceStatusOK     SourceStatus = "ok"
+	SourceStatusFailed SourceStatus = "failed"
+
+	ErrorClassTimeout     ErrorClass = "timeout"
+	ErrorClassCanceled    ErrorClass = "canceled"
+	ErrorClassUnavailable ErrorClass = "unavailable"
+	ErrorClassUpstream    ErrorClass = "upstream"
+
+	ClusterModeNone     ClusterMode = ""
+	ClusterModeCollapse ClusterMode = "collapse"
+	ClusterModeAnnotate ClusterMode = "annotate"
+
+	// MaxHistoryItems is the max offset plus limit of a query with history, as every
+	// item up to the end of the page is read from each source. Later pages are reached
+	// by cursor.
+	MaxHistoryItems = 1000
 )
 
 type (
-	Provider string
-	Category string
+	Provider     string
+	Category     string
+	MediaKind    string
+	SourceStatus string
+	ErrorClass   string
+
+	// ClusterMode is how items from different providers about the same story are
+	// returned: collapsed into a single item, or annotated with the cluster they're in.
+	ClusterMode string
 
 	Feed struct {
 		Title       string    `json:"title"`
@@ -24,6 +51,46 @@ type (
 		DateTime    time.Time `json:"dateTime"`
 		TTL         int       `json:"ttl"`
 		Items       []Item    `json:"items"`
+
+		// FetchedAt is when the feed was fetched from its provider.
+		FetchedAt time.Time `json:"fetchedAt,omitempty"`
+		// SkippedItems is the number of items dropped because they couldn't be parsed.
+		SkippedItems int `json:"skippedItems,omitempty"`
+		// SkipHours are the hours (0-23, UTC) in which the feed asks not to be polled.
+		SkipHours []int `json:"skipHours,omitempty"`
+		// SkipDays are the days on which the feed asks not to be polled.
+		SkipDays []time.Weekday `json:"skipDays,omitempty"`
+	}
+
+	// Query selects the items returned for a feed.
+	Query struct {
+		// Cursor starts the page after the item at the cursor, before Offset is applied.
+		Cursor *Cursor
+		Offset int
+		Limit  int
+		// History includes archived items that are no longer in the live feeds.
+		History bool
+		Cluster ClusterMode
+		// Since, Until and MaxAge select the items published at or after Since, at or
+		// before Until, and within MaxAge of now, when they're set.
+		Since  time.Time
+		Until  time.Time
+		MaxAge time.Duration
+		// Providers and Categories select the feeds of several providers and categories
+		// rather than one, or all of them. ExcludeProviders and ExcludeCategories remove
+		// feeds from the selection.
+		Providers         []Provider
+		Categories        []Category
+		ExcludeProviders  []Provider
+		ExcludeCategories []Category
+	}
+
+	// SearchQuery selects the items matching Text. Searches cover every indexed item,
+	// so History doesn't apply, and are ranked by relevance rather than date, so
+	// Cursor doesn't apply.
+	SearchQuery struct {
+		Query
+		Text string
 	}
 
 	FeedResponse struct {
@@ -32,15 +99,99 @@ type (
 		Items    []Item   `json:"items"`
 		Limit    int      `json:"limit,omitempty"`
 		Offset   int      `json:"offset,omitempty"`
+		// Total is the number of items matching the query, across every page. It's
+		// unset with history, as the archive is only read up to the item after the page.
+		Total *int `json:"total,omitempty"`
+		// HasMore is set when there are items after this page.
+		HasMore bool `json:"hasMore"`
+		// NextCursor is the cursor of the next page, set when there are more items.
+		NextCursor string `json:"nextCursor,omitempty"`
+		// Next and Prev are the absolute URLs of the next and previous pages.
+		Next    string   `json:"next,omitempty"`
+		Prev    string   `json:"prev,omitempty"`
+		Sources []Source `json:"sources"`
+		// Degraded is set when the items of at least one source are missing.
+		Degraded bool `json:"degraded,omitempty"`
+	}
+
+	// Source is the outcome of getting the feed for a provider and category.
+	Source struct {
+		Provider Provider     `json:"provider"`
+		Category Category     `json:"category"`
+		Status   SourceStatus `json:"status"`
+		Error    ErrorClass   `json:"error,omitempty"`
+		Cached   bool         `json:"cached"`
+		// Stale is set when the feed is served from the cache after its TTL has passed.
+		Stale bool `json:"stale,omitempty"`
+		// SkippedItems is the number of items of the feed dropped because they couldn't
+		// be parsed, e.g. for a missing or invalid date.
+		SkippedItems int `json:"skippedItems,omitempty"`
 	}
 
 	Item struct {
-		Category    Category  `json:"category"`
-		Provider    Provider  `json:"provider"`
-		Title       string    `json:"title"`
-		Link        string    `json:"link"`
-		Description string    `json:"description"`
-		Thumbnail   string    `json:"thumbnail"`
-		DateTime    time.Time `json:"dateTime"`
+		// Category is the category of the feed the item is in. Items of the feed of
+		// every category list their categories in Categories instead.
+		Category    Category   `json:"category,omitempty"`
+		Categories  []Category `json:"categories,omitempty"`
+		Provider    Provider   `json:"provider"`
+		Title       string     `json:"title"`
+		Link        string     `json:"link"`
+		Description string     `json:"description"`
+		Thumbnail   string     `json:"thumbnail"`
+		DateTime    time.Time  `json:"dateTime"`
+
+		// GUID identifies the item within its provider, falling back to the link
+		// when the feed doesn't set one.
+		GUID       string      `json:"guid,omitempty"`
+		Authors    []string    `json:"authors,omitempty"`
+		Tags       []string    `json:"tags,omitempty"`
+		Enclosures []Enclosure `json:"enclosures,omitempty"`
+		Media      []Media     `json:"media,omitempty"`
+
+		// ClusterID identifies the cluster of items from different providers about the
+		// same story, when clustered items are annotated or collapsed.
+		ClusterID string `json:"clusterId,omitempty"`
+		// Alternates are the items of other providers about the same story, when
+		// clustered items are collapsed.
+		Alternates []Alternate `json:"alternates,omitempty"`
+
+		// Score is the relevance of the item to a search.
+		Score float64 `json:"score,omitempty"`
+		// Highlight is the title and description of the item with the words matching
+		// a search emphasized.
+		Highlight *Highlight `json:"highlight,omitempty"`
+	}
+
+	// Highlight is a snippet of the title and description of an item, HTML escaped,
+	// with the words matching a search wrapped in <em> tags. A field is empty if none
+	// of its words match.
+	Highlight struct {
+		Title       string `json:"title,omitempty"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// Alternate is an item of another provider about the same story as an item.
+	Alternate struct {
+		Provider   Provider   `json:"provider"`
+		Category   Category   `json:"category,omitempty"`
+		Categories []Category `json:"categories,omitempty"`
+		Title      string     `json:"title"`
+		Link       string     `json:"link"`
+		DateTime   time.Time  `json:"dateTime"`
+	}
+
+	Enclosure struct {
+		URL    string `json:"url"`
+		Type   string `json:"type,omitempty"`
+		Length int64  `json:"length,omitempty"`
+	}
+
+	Media struct {
+		Kind   MediaKind `json:"kind"`
+		URL    string    `json:"url"`
+		Type   string    `json:"type,omitempty"`
+		Medium string    `json:"medium,omitempty"`
+		Width  int       `json:"width,omitempty"`
+		Height int       `json:"height,omitempty"`
 	}
 )
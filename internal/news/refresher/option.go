@@ -0,0 +1,50 @@
+package refresher
+
+import (
+	"time"
+
+	"github.com/cshep4/news-api/internal/news"
+)
+
+type Option func(*refresher)
+
+// WithSource adds the feeds of the given categories of a provider to the sources
+// that are refreshed.
+func WithSource(name news.Provider, provider Provider, categories ...news.Category) Option {
+	return func(r *refresher) {
+		for _, c := range categories {
+			r.sources = append(r.sources, source{name: name, provider: provider, category: c})
+		}
+	}
+}
+
+// WithInterval sets how often feeds that don't specify a TTL are refreshed. It
+// should match the default TTL of the cache.
+func WithInterval(interval time.Duration) Option {
+	return func(r *refresher) {
+		r.interval = interval
+	}
+}
+
+// WithJitter sets the max fraction of the interval, between 0 and 1, by which each
+// refresh is randomly brought forward, so that refreshes are spread out.
+func WithJitter(jitter float64) Option {
+	return func(r *refresher) {
+		r.jitter = jitter
+	}
+}
+
+// WithRateLimit sets the min time between requests to a provider.
+func WithRateLimit(name news.Provider, interval time.Duration) Option {
+	return func(r *refresher) {
+		r.rateLimits[name] = interval
+	}
+}
+
+// WithIndex sets the search index that refreshed feeds are added to, so that items
+// can be searched once they're in the cache.
+func WithIndex(index Index) Option {
+	return func(r *refresher) {
+		r.index = index
+	}
+}
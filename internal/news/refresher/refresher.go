@@ -0,0 +1,281 @@
+package refresher
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/cshep4/news-api/internal/log"
+	"github.com/cshep4/news-api/internal/news"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultJitter   = 0.1
+
+	// maxSkippedHours bounds the search for an hour that isn't skipped, in case a
+	// feed skips every hour of the week.
+	maxSkippedHours = 24 * 7
+)
+
+type (
+	Provider interface {
+		GetFeed(ctx context.Context, category news.Category) (*news.Feed, error)
+	}
+
+	Cache interface {
+		Store(provider news.Provider, category news.Category, feed news.Feed)
+	}
+
+	Index interface {
+		Index(items []news.Item)
+	}
+
+	// Status is the outcome of the last refresh of a provider's category feed.
+	Status struct {
+		Provider    news.Provider `json:"provider"`
+		Category    news.Category `json:"category"`
+		LastRun     time.Time     `json:"lastRun"`
+		LastSuccess time.Time     `json:"lastSuccess"`
+		NextRun     time.Time     `json:"nextRun"`
+		Error       string        `json:"error,omitempty"`
+	}
+
+	source struct {
+		name     news.Provider
+		provider Provider
+		category news.Category
+	}
+
+	// limiter spaces out requests to a provider.
+	limiter struct {
+		mutex    sync.Mutex
+		interval time.Duration
+		next     time.Time
+	}
+
+	refresher struct {
+		cache      Cache
+		index      Index
+		clock      clockwork.Clock
+		sources    []source
+		interval   time.Duration
+		jitter     float64
+		rateLimits map[news.Provider]time.Duration
+		limiters   map[news.Provider]*limiter
+		random     *rand.Rand
+
+		mutex    sync.Mutex
+		statuses []Status
+	}
+)
+
+// New creates a refresher that keeps the cache warm by polling the feed of each
+// source before it expires. Feeds are polled after their TTL, or the default
+// interval if they don't specify one, brought forward by a random jitter so that
+// they're refreshed before they go stale. Hours and days that a feed asks not to be
+// polled in are skipped, and requests to each provider are rate limited.
+func New(clock clockwork.Clock, cache Cache, opts ...Option) (*refresher, error) {
+	switch {
+	case clock == nil:
+		return nil, news.InvalidParameterError{Parameter: "clock"}
+	case cache == nil:
+		return nil, news.InvalidParameterError{Parameter: "cache"}
+	}
+
+	r := &refresher{
+		cache:      cache,
+		clock:      clock,
+		interval:   defaultInterval,
+		jitter:     defaultJitter,
+		rateLimits: make(map[news.Provider]time.Duration),
+		limiters:   make(map[news.Provider]*limiter),
+		random:     rand.New(rand.NewSource(clock.Now().UnixNano())),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	switch {
+	case r.interval <= 0:
+		return nil, news.InvalidParameterError{Parameter: "interval"}
+	case r.jitter < 0 || r.jitter >= 1:
+		return nil, news.InvalidParameterError{Parameter: "jitter"}
+	}
+
+	for p, rateLimit := range r.rateLimits {
+		if rateLimit < 0 {
+			return nil, news.InvalidParameterError{Parameter: "rateLimit"}
+		}
+		r.limiters[p] = &limiter{interval: rateLimit}
+	}
+
+	r.statuses = make([]Status, 0, len(r.sources))
+	for _, s := range r.sources {
+		switch {
+		case s.name == news.ProviderAll:
+			return nil, news.InvalidParameterError{Parameter: "name"}
+		case s.provider == nil:
+			return nil, news.InvalidParameterError{Parameter: "provider"}
+		case s.category == "":
+			return nil, news.InvalidParameterError{Parameter: "category"}
+		}
+
+		if _, ok := r.limiters[s.name]; !ok {
+			r.limiters[s.name] = &limiter{}
+		}
+		r.statuses = append(r.statuses, Status{Provider: s.name, Category: s.category})
+	}
+
+	return r, nil
+}
+
+// Start refreshes every source until ctx is done.
+func (r *refresher) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := range r.sources {
+		i := i
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.run(ctx, i)
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// Status returns the outcome of the last refresh of each source.
+func (r *refresher) Status() []Status {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]Status(nil), r.statuses...)
+}
+
+// run refreshes the source at index i, starting immediately.
+func (r *refresher) run(ctx context.Context, i int) {
+	var (
+		s     = r.sources[i]
+		feed  *news.Feed
+		delay time.Duration
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.clock.After(delay):
+		}
+
+		if err := r.limiters[s.name].wait(ctx, r.clock); err != nil {
+			return
+		}
+
+		f, err := s.provider.GetFeed(ctx, s.category)
+		if ctx.Err() != nil {
+			return
+		}
+
+		now := r.clock.Now()
+		interval := r.interval
+		if err == nil {
+			fetched := *f
+			fetched.FetchedAt = now
+			feed = &fetched
+			r.cache.Store(s.name, s.category, fetched)
+			if r.index != nil {
+				r.index.Index(feed.Items)
+			}
+			if feed.TTL > 0 {
+				interval = time.Duration(feed.TTL) * time.Minute
+			}
+		} else {
+			log.Info(ctx, "error_refreshing_feed",
+				log.SafeParam("provider", s.name),
+				log.SafeParam("category", s.category),
+				log.ErrorParam(err),
+			)
+		}
+
+		next := now.Add(r.jittered(interval))
+		if feed != nil {
+			next = nextRun(next, feed.SkipHours, feed.SkipDays)
+		}
+
+		r.setStatus(i, now, next, err)
+
+		delay = next.Sub(now)
+	}
+}
+
+// jittered brings interval forward by a random fraction of up to jitter.
+func (r *refresher) jittered(interval time.Duration) time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return interval - time.Duration(r.random.Float64()*r.jitter*float64(interval))
+}
+
+func (r *refresher) setStatus(i int, now, next time.Time, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	status := &r.statuses[i]
+	status.LastRun = now
+	status.NextRun = next
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+		return
+	}
+	status.LastSuccess = now
+}
+
+// wait blocks until the next request to the provider is allowed.
+func (l *limiter) wait(ctx context.Context, clock clockwork.Clock) error {
+	l.mutex.Lock()
+	now := clock.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(at.Sub(now)):
+		return nil
+	}
+}
+
+// nextRun returns t, or the start of the first hour after it that isn't skipped.
+func nextRun(t time.Time, skipHours []int, skipDays []time.Weekday) time.Time {
+	for i := 0; i < maxSkippedHours && skipped(t, skipHours, skipDays); i++ {
+		t = t.Truncate(time.Hour).Add(time.Hour)
+	}
+	return t
+}
+
+func skipped(t time.Time, skipHours []int, skipDays []time.Weekday) bool {
+	t = t.UTC()
+	for _, h := range skipHours {
+		if t.Hour() == h {
+			return true
+		}
+	}
+	for _, d := range skipDays {
+		if t.Weekday() == d {
+			return true
+		}
+	}
+	return false
+}
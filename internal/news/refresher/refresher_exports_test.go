@@ -0,0 +1,3 @@
+package refresher
+
+var NextRun = nextRun
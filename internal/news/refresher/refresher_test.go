@@ -0,0 +1,337 @@
+package refresher_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cache_mock "github.com/cshep4/news-api/internal/mock/cache"
+	provider_mock "github.com/cshep4/news-api/internal/mock/provider"
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/news/refresher"
+	"github.com/cshep4/news-api/internal/news/search"
+)
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+// start runs the refresher until the returned function is called.
+func start(t *testing.T, r interface{ Start(context.Context) error }) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, r.Start(ctx))
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+func TestNew_Error(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	provider := provider_mock.NewMockProvider(nil)
+
+	testCases := []struct {
+		name                   string
+		clock                  clockwork.Clock
+		cache                  refresher.Cache
+		opts                   []refresher.Option
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "clock is empty",
+			clock:                  nil,
+			cache:                  cache_mock.NewMockCache(nil),
+			expectedErrorParameter: "clock",
+		},
+		{
+			name:                   "cache is empty",
+			clock:                  clock,
+			cache:                  nil,
+			expectedErrorParameter: "cache",
+		},
+		{
+			name:                   "interval is invalid",
+			clock:                  clock,
+			cache:                  cache_mock.NewMockCache(nil),
+			opts:                   []refresher.Option{refresher.WithInterval(0)},
+			expectedErrorParameter: "interval",
+		},
+		{
+			name:                   "jitter is invalid",
+			clock:                  clock,
+			cache:                  cache_mock.NewMockCache(nil),
+			opts:                   []refresher.Option{refresher.WithJitter(1)},
+			expectedErrorParameter: "jitter",
+		},
+		{
+			name:                   "rate limit is invalid",
+			clock:                  clock,
+			cache:                  cache_mock.NewMockCache(nil),
+			opts:                   []refresher.Option{refresher.WithRateLimit(news.ProviderBBC, -time.Second)},
+			expectedErrorParameter: "rateLimit",
+		},
+		{
+			name:                   "source name is empty",
+			clock:                  clock,
+			cache:                  cache_mock.NewMockCache(nil),
+			opts:                   []refresher.Option{refresher.WithSource("", provider, news.CategoryUK)},
+			expectedErrorParameter: "name",
+		},
+		{
+			name:                   "source provider is empty",
+			clock:                  clock,
+			cache:                  cache_mock.NewMockCache(nil),
+			opts:                   []refresher.Option{refresher.WithSource(news.ProviderBBC, nil, news.CategoryUK)},
+			expectedErrorParameter: "provider",
+		},
+		{
+			name:                   "source category is empty",
+			clock:                  clock,
+			cache:                  cache_mock.NewMockCache(nil),
+			opts:                   []refresher.Option{refresher.WithSource(news.ProviderBBC, provider, "")},
+			expectedErrorParameter: "category",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := refresher.New(tc.clock, tc.cache, tc.opts...)
+			require.Error(t, err)
+			require.Nil(t, r)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestRefresher_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clock := clockwork.NewFakeClock()
+	cache := cache_mock.NewMockCache(ctrl)
+	provider := provider_mock.NewMockProvider(ctrl)
+
+	feed := news.Feed{Title: "feed", TTL: 10}
+
+	r, err := refresher.New(clock, cache,
+		refresher.WithSource(news.ProviderBBC, provider, news.CategoryUK),
+		refresher.WithJitter(0),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []refresher.Status{{Provider: news.ProviderBBC, Category: news.CategoryUK}}, r.Status())
+
+	provider.EXPECT().GetFeed(gomock.Any(), news.CategoryUK).Return(&feed, nil).Times(2)
+	cache.EXPECT().Store(news.ProviderBBC, news.CategoryUK, gomock.Any()).Do(func(_ news.Provider, _ news.Category, f news.Feed) {
+		assert.Equal(t, clock.Now(), f.FetchedAt)
+	}).Times(2)
+
+	stop := start(t, r)
+	defer stop()
+
+	// the feed is refreshed immediately, then after its TTL
+	clock.BlockUntil(1)
+	first := clock.Now()
+	assert.Equal(t, []refresher.Status{{
+		Provider:    news.ProviderBBC,
+		Category:    news.CategoryUK,
+		LastRun:     first,
+		LastSuccess: first,
+		NextRun:     first.Add(10 * time.Minute),
+	}}, r.Status())
+
+	clock.Advance(10 * time.Minute)
+	clock.BlockUntil(1)
+	second := clock.Now()
+	assert.Equal(t, []refresher.Status{{
+		Provider:    news.ProviderBBC,
+		Category:    news.CategoryUK,
+		LastRun:     second,
+		LastSuccess: second,
+		NextRun:     second.Add(10 * time.Minute),
+	}}, r.Status())
+}
+
+func TestRefresher_Start_Index(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clock := clockwork.NewFakeClock()
+	cache := cache_mock.NewMockCache(ctrl)
+	provider := provider_mock.NewMockProvider(ctrl)
+
+	index, err := search.New()
+	require.NoError(t, err)
+
+	item := news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "1", Title: "Floods hit northern England"}
+	feed := news.Feed{Items: []news.Item{item}}
+
+	r, err := refresher.New(clock, cache,
+		refresher.WithSource(news.ProviderBBC, provider, news.CategoryUK),
+		refresher.WithIndex(index),
+	)
+	require.NoError(t, err)
+
+	provider.EXPECT().GetFeed(gomock.Any(), news.CategoryUK).Return(&feed, nil)
+	cache.EXPECT().Store(news.ProviderBBC, news.CategoryUK, news.Feed{Items: feed.Items, FetchedAt: clock.Now()})
+
+	stop := start(t, r)
+	clock.BlockUntil(1)
+	stop()
+
+	items := index.Search(search.Query{Text: "floods"})
+	require.Len(t, items, 1)
+	assert.Equal(t, item.Title, items[0].Title)
+}
+
+func TestRefresher_Start_Error(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clock := clockwork.NewFakeClock()
+	cache := cache_mock.NewMockCache(ctrl)
+	provider := provider_mock.NewMockProvider(ctrl)
+
+	r, err := refresher.New(clock, cache,
+		refresher.WithSource(news.ProviderBBC, provider, news.CategoryUK),
+		refresher.WithInterval(5*time.Minute),
+		refresher.WithJitter(0),
+	)
+	require.NoError(t, err)
+
+	provider.EXPECT().GetFeed(gomock.Any(), news.CategoryUK).Return(nil, testError("error"))
+
+	stop := start(t, r)
+	defer stop()
+
+	clock.BlockUntil(1)
+	now := clock.Now()
+	assert.Equal(t, []refresher.Status{{
+		Provider: news.ProviderBBC,
+		Category: news.CategoryUK,
+		LastRun:  now,
+		NextRun:  now.Add(5 * time.Minute),
+		Error:    "error",
+	}}, r.Status())
+}
+
+func TestRefresher_Start_RateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clock := clockwork.NewFakeClock()
+	cache := cache_mock.NewMockCache(ctrl)
+	provider := provider_mock.NewMockProvider(ctrl)
+
+	r, err := refresher.New(clock, cache,
+		refresher.WithSource(news.ProviderBBC, provider, news.CategoryUK, news.CategoryTechnology),
+		refresher.WithRateLimit(news.ProviderBBC, time.Minute),
+		refresher.WithJitter(0),
+	)
+	require.NoError(t, err)
+
+	var (
+		mutex    sync.Mutex
+		requests []time.Time
+	)
+	provider.EXPECT().GetFeed(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, news.Category) (*news.Feed, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests = append(requests, clock.Now())
+		return &news.Feed{TTL: 10}, nil
+	}).Times(2)
+	cache.EXPECT().Store(news.ProviderBBC, gomock.Any(), gomock.Any()).Times(2)
+
+	stop := start(t, r)
+	defer stop()
+
+	// one source has been refreshed and the other is waiting for the rate limit
+	clock.BlockUntil(2)
+	first := clock.Now()
+
+	clock.Advance(time.Minute)
+	clock.BlockUntil(2)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []time.Time{first, first.Add(time.Minute)}, requests)
+}
+
+func TestNextRun(t *testing.T) {
+	// Saturday 6 February 2021
+	saturday := time.Date(2021, 2, 6, 20, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		t         time.Time
+		skipHours []int
+		skipDays  []time.Weekday
+		expected  time.Time
+	}{
+		{
+			name:     "nothing skipped",
+			t:        saturday,
+			expected: saturday,
+		},
+		{
+			name:      "hour not skipped",
+			t:         saturday,
+			skipHours: []int{19, 21},
+			expected:  saturday,
+		},
+		{
+			name:      "skipped hours",
+			t:         saturday,
+			skipHours: []int{20, 21},
+			expected:  time.Date(2021, 2, 6, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "skipped hours in utc",
+			t:         saturday.In(time.FixedZone("UTC+1", 60*60)),
+			skipHours: []int{20},
+			expected:  time.Date(2021, 2, 6, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "skipped days",
+			t:        saturday,
+			skipDays: []time.Weekday{time.Saturday, time.Sunday},
+			expected: time.Date(2021, 2, 8, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "skipped hours and days",
+			t:         saturday,
+			skipHours: []int{0, 1},
+			skipDays:  []time.Weekday{time.Saturday, time.Sunday},
+			expected:  time.Date(2021, 2, 8, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "every day skipped",
+			t:        saturday,
+			skipDays: []time.Weekday{0, 1, 2, 3, 4, 5, 6},
+			expected: time.Date(2021, 2, 13, 20, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.True(t, tc.expected.Equal(refresher.NextRun(tc.t, tc.skipHours, tc.skipDays)))
+		})
+	}
+}
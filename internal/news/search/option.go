@@ -0,0 +1,11 @@
+package search
+
+type Option func(*index)
+
+// WithMaxItems sets the max number of items in the index, defaulting to 10000. The
+// oldest items are removed once it's full.
+func WithMaxItems(items int) Option {
+	return func(i *index) {
+		i.maxItems = items
+	}
+}
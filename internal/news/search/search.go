@@ -0,0 +1,419 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/cshep4/news-api/internal/news"
+)
+
+const (
+	defaultMaxItems = 10000
+
+	// k1 and b are the BM25 parameters that control how quickly repeated terms stop
+	// adding to the score, and how much long documents are penalised.
+	k1 = 1.2
+	b  = 0.75
+
+	// titleWeight is how many times each term of a title is counted, as a match in
+	// the title says more about an item than a match in its description.
+	titleWeight = 2
+
+	// snippetWords is the max number of words of a description that are highlighted.
+	snippetWords = 30
+	// snippetLead is the number of words shown before the first match in a snippet.
+	snippetLead = 5
+)
+
+// stopWords are common words that don't help to find an item.
+var stopWords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "be": {}, "but": {},
+	"by": {}, "for": {}, "from": {}, "has": {}, "have": {}, "in": {}, "is": {}, "it": {},
+	"its": {}, "of": {}, "on": {}, "or": {}, "that": {}, "the": {}, "this": {}, "to": {},
+	"was": {}, "were": {}, "will": {}, "with": {},
+}
+
+type (
+	// Query selects the items that match Text. Items are only matched if they're from
+	// one of Providers and in one of Categories, when they're set, and were published
+	// between Since and Until, when they're set.
+	Query struct {
+		Text       string
+		Providers  []news.Provider
+		Categories []news.Category
+		Since      time.Time
+		Until      time.Time
+	}
+
+	index struct {
+		maxItems int
+
+		mutex sync.RWMutex
+		docs  map[string]*document
+		// postings maps each term to the number of times it's in each document.
+		postings map[string]map[string]int
+		// length is the total length of the documents.
+		length int
+	}
+
+	document struct {
+		item       news.Item
+		categories []news.Category
+		terms      map[string]int
+		length     int
+	}
+
+	// span is the position of a word in a text.
+	span struct {
+		start, end int
+	}
+)
+
+// New creates an in-memory inverted index of items, ranked by BM25. Items are kept
+// once they're no longer in their feed, until the index is full and they're the oldest.
+func New(opts ...Option) (*index, error) {
+	i := &index{
+		maxItems: defaultMaxItems,
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]int),
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	if i.maxItems <= 0 {
+		return nil, news.InvalidParameterError{Parameter: "maxItems"}
+	}
+
+	return i, nil
+}
+
+// Index adds items to the index, replacing any previous version of an item. An item
+// that's in several categories is indexed once, listing each of its categories.
+func (i *index) Index(items []news.Item) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	for _, item := range items {
+		id := item.ID()
+		categories := item.Categories
+		if item.Category != "" {
+			categories = append([]news.Category{item.Category}, categories...)
+		}
+
+		if doc, ok := i.docs[id]; ok {
+			for _, c := range categories {
+				if !hasCategory(doc.categories, c) {
+					doc.categories = append(doc.categories, c)
+				}
+			}
+			categories = doc.categories
+
+			if unchanged(doc.item, item) {
+				doc.item = item
+				continue
+			}
+			i.remove(id)
+		}
+
+		i.add(id, item, categories)
+	}
+
+	i.evict()
+}
+
+// Search returns the items matching query, most relevant first. Each item is scored
+// and has the words matching the query highlighted.
+func (i *index) Search(query Query) []news.Item {
+	terms := queryTerms(query.Text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if len(i.docs) == 0 {
+		return nil
+	}
+
+	var (
+		scores    = make(map[string]float64)
+		n         = float64(len(i.docs))
+		avgLength = float64(i.length) / n
+	)
+	for t := range terms {
+		postings := i.postings[t]
+		if len(postings) == 0 {
+			continue
+		}
+
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			doc := i.docs[id]
+			if !matches(doc, query) {
+				continue
+			}
+
+			norm := k1 * (1 - b + b*float64(doc.length)/avgLength)
+			scores[id] += idf * float64(tf) * (k1 + 1) / (float64(tf) + norm)
+		}
+	}
+
+	items := make([]news.Item, 0, len(scores))
+	for id, score := range scores {
+		doc := i.docs[id]
+
+		item := doc.item
+		item.Category = ""
+		item.Categories = append([]news.Category(nil), doc.categories...)
+		item.Score = score
+		item.Highlight = &news.Highlight{
+			Title:       highlight(item.Title, terms, 0),
+			Description: highlight(item.Description, terms, snippetWords),
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		if !items[i].DateTime.Equal(items[j].DateTime) {
+			return items[i].DateTime.After(items[j].DateTime)
+		}
+		return items[i].Link < items[j].Link
+	})
+
+	return items
+}
+
+// add indexes item under id. The caller must hold the lock.
+func (i *index) add(id string, item news.Item, categories []news.Category) {
+	terms := make(map[string]int)
+	for _, t := range tokens(item.Title) {
+		terms[t] += titleWeight
+	}
+	for _, t := range tokens(item.Description) {
+		terms[t]++
+	}
+
+	doc := &document{
+		item:       item,
+		categories: categories,
+		terms:      terms,
+	}
+	for t, tf := range terms {
+		if i.postings[t] == nil {
+			i.postings[t] = make(map[string]int)
+		}
+		i.postings[t][id] = tf
+		doc.length += tf
+	}
+
+	i.docs[id] = doc
+	i.length += doc.length
+}
+
+// remove removes the document with id from the index. The caller must hold the lock.
+func (i *index) remove(id string) {
+	doc, ok := i.docs[id]
+	if !ok {
+		return
+	}
+
+	for t := range doc.terms {
+		delete(i.postings[t], id)
+		if len(i.postings[t]) == 0 {
+			delete(i.postings, t)
+		}
+	}
+
+	delete(i.docs, id)
+	i.length -= doc.length
+}
+
+// evict removes the oldest documents once there are more than the max. The caller
+// must hold the lock.
+func (i *index) evict() {
+	if len(i.docs) <= i.maxItems {
+		return
+	}
+
+	ids := make([]string, 0, len(i.docs))
+	for id := range i.docs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(x, y int) bool {
+		return i.docs[ids[x]].item.DateTime.Before(i.docs[ids[y]].item.DateTime)
+	})
+
+	for _, id := range ids[:len(ids)-i.maxItems] {
+		i.remove(id)
+	}
+}
+
+// unchanged reports whether the indexed text of an item is the same.
+func unchanged(indexed, item news.Item) bool {
+	return indexed.Title == item.Title &&
+		indexed.Description == item.Description &&
+		indexed.DateTime.Equal(item.DateTime)
+}
+
+func matches(doc *document, query Query) bool {
+	item := doc.item
+	switch {
+	case !query.Since.IsZero() && item.DateTime.Before(query.Since),
+		!query.Until.IsZero() && item.DateTime.After(query.Until):
+		return false
+	case len(query.Providers) > 0 && !hasProvider(query.Providers, item.Provider):
+		return false
+	}
+
+	if len(query.Categories) == 0 {
+		return true
+	}
+	for _, c := range doc.categories {
+		if hasCategory(query.Categories, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryTerms returns the distinct terms of a query.
+func queryTerms(text string) map[string]struct{} {
+	terms := make(map[string]struct{})
+	for _, t := range tokens(text) {
+		terms[t] = struct{}{}
+	}
+	return terms
+}
+
+// tokens returns the stemmed words of text, without stop words.
+func tokens(text string) []string {
+	var tokens []string
+	for _, s := range words(text) {
+		if t, ok := token(text[s.start:s.end]); ok {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// token returns the term a word is indexed under, or false if it isn't indexed.
+func token(word string) (string, bool) {
+	word = strings.ToLower(word)
+	if _, ok := stopWords[word]; ok || len(word) < 2 {
+		return "", false
+	}
+	return stem(word), true
+}
+
+// words returns the position of each word in text.
+func words(text string) []span {
+	var (
+		spans []span
+		start = -1
+	)
+	for i, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if start < 0 {
+				start = i
+			}
+		case start >= 0:
+			spans = append(spans, span{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start: start, end: len(text)})
+	}
+	return spans
+}
+
+// highlight returns text, HTML escaped, with the words matching terms wrapped in
+// <em> tags, or an empty string if no words match. If maxWords is set, only that
+// many words from just before the first match are returned.
+func highlight(text string, terms map[string]struct{}, maxWords int) string {
+	spans := words(text)
+
+	first := -1
+	for i, s := range spans {
+		if t, ok := token(text[s.start:s.end]); ok {
+			if _, ok := terms[t]; ok {
+				first = i
+				break
+			}
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start, end := 0, len(spans)
+	if maxWords > 0 && len(spans) > maxWords {
+		start = first - snippetLead
+		if start < 0 {
+			start = 0
+		}
+		end = start + maxWords
+		if end > len(spans) {
+			end = len(spans)
+			start = end - maxWords
+		}
+	}
+
+	var (
+		sb  strings.Builder
+		pos = 0
+	)
+	if start > 0 {
+		sb.WriteString("…")
+		pos = spans[start].start
+	}
+	for _, s := range spans[start:end] {
+		sb.WriteString(html.EscapeString(text[pos:s.start]))
+
+		word := html.EscapeString(text[s.start:s.end])
+		t, ok := token(text[s.start:s.end])
+		if _, match := terms[t]; ok && match {
+			word = "<em>" + word + "</em>"
+		}
+		sb.WriteString(word)
+		pos = s.end
+	}
+	if end < len(spans) {
+		sb.WriteString("…")
+	} else {
+		sb.WriteString(html.EscapeString(text[pos:]))
+	}
+
+	return sb.String()
+}
+
+func hasProvider(providers []news.Provider, provider news.Provider) bool {
+	for _, p := range providers {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCategory(categories []news.Category, category news.Category) bool {
+	for _, c := range categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
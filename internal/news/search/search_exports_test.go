@@ -0,0 +1,3 @@
+package search
+
+var Stem = stem
@@ -0,0 +1,230 @@
+package search_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/news/search"
+)
+
+func TestNew_Error(t *testing.T) {
+	i, err := search.New(search.WithMaxItems(0))
+	require.Error(t, err)
+	require.Nil(t, i)
+
+	ipe, ok := err.(news.InvalidParameterError)
+	require.True(t, ok)
+
+	assert.Equal(t, "maxItems", ipe.Parameter)
+}
+
+func TestStem(t *testing.T) {
+	testCases := map[string]string{
+		"caresses":        "caress",
+		"ponies":          "poni",
+		"cats":            "cat",
+		"agreed":          "agre",
+		"feed":            "feed",
+		"plastered":       "plaster",
+		"motoring":        "motor",
+		"sing":            "sing",
+		"conflated":       "conflat",
+		"hopping":         "hop",
+		"falling":         "fall",
+		"filing":          "file",
+		"happy":           "happi",
+		"relational":      "relat",
+		"conditional":     "condit",
+		"digitizer":       "digit",
+		"hopefulness":     "hope",
+		"formalize":       "formal",
+		"electrical":      "electr",
+		"replacement":     "replac",
+		"adoption":        "adopt",
+		"controll":        "control",
+		"generalizations": "gener",
+		"oscillators":     "oscil",
+		"flooding":        "flood",
+		"floods":          "flood",
+		"café":            "café",
+	}
+
+	for word, expected := range testCases {
+		assert.Equal(t, expected, search.Stem(word), word)
+	}
+}
+
+func TestIndex_Search(t *testing.T) {
+	now := time.Now()
+
+	var (
+		floods = news.Item{
+			Provider:    news.ProviderBBC,
+			Category:    news.CategoryUK,
+			GUID:        "1",
+			Title:       "Floods hit northern England",
+			Description: "Homes are evacuated as rivers burst their banks after days of rain.",
+			DateTime:    now,
+		}
+		rain = news.Item{
+			Provider:    news.ProviderSky,
+			Category:    news.CategoryUK,
+			GUID:        "2",
+			Title:       "More rain on the way",
+			Description: "Forecasters warn of further flooding in the north.",
+			DateTime:    now.Add(-time.Hour),
+		}
+		chips = news.Item{
+			Provider:    news.ProviderBBC,
+			Category:    news.CategoryTechnology,
+			GUID:        "3",
+			Title:       "Chip shortage hits car makers",
+			Description: "Production lines are halted <again>.",
+			DateTime:    now.Add(-2 * time.Hour),
+		}
+	)
+
+	index, err := search.New()
+	require.NoError(t, err)
+
+	index.Index([]news.Item{floods, rain, chips})
+
+	titles := func(items []news.Item) []string {
+		var titles []string
+		for _, i := range items {
+			titles = append(titles, i.Title)
+		}
+		return titles
+	}
+
+	testCases := []struct {
+		name           string
+		query          search.Query
+		expectedTitles []string
+	}{
+		{
+			name:           "title matches rank first",
+			query:          search.Query{Text: "flooded"},
+			expectedTitles: []string{"Floods hit northern England", "More rain on the way"},
+		},
+		{
+			name:           "any term matches",
+			query:          search.Query{Text: "chips rain"},
+			expectedTitles: []string{"Chip shortage hits car makers", "More rain on the way", "Floods hit northern England"},
+		},
+		{
+			name:           "provider filter",
+			query:          search.Query{Text: "flood", Providers: []news.Provider{news.ProviderSky}},
+			expectedTitles: []string{"More rain on the way"},
+		},
+		{
+			name:           "category filter",
+			query:          search.Query{Text: "hits", Categories: []news.Category{news.CategoryTechnology}},
+			expectedTitles: []string{"Chip shortage hits car makers"},
+		},
+		{
+			name:           "date filter",
+			query:          search.Query{Text: "flood rain", Since: now.Add(-90 * time.Minute), Until: now.Add(-30 * time.Minute)},
+			expectedTitles: []string{"More rain on the way"},
+		},
+		{
+			name:  "only stop words",
+			query: search.Query{Text: "the of"},
+		},
+		{
+			name:  "no matches",
+			query: search.Query{Text: "election"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			items := index.Search(tc.query)
+			assert.Equal(t, tc.expectedTitles, titles(items))
+
+			for _, i := range items {
+				assert.Greater(t, i.Score, 0.0)
+			}
+		})
+	}
+}
+
+func TestIndex_Search_Highlight(t *testing.T) {
+	index, err := search.New()
+	require.NoError(t, err)
+
+	index.Index([]news.Item{
+		{
+			Provider:    news.ProviderBBC,
+			Category:    news.CategoryTechnology,
+			Title:       "Chip shortage hits car makers",
+			Description: "Production lines are halted <again> as the chip shortage continues.",
+		},
+		{
+			Provider:    news.ProviderBBC,
+			Category:    news.CategoryUK,
+			Title:       "Long read",
+			Description: strings.Repeat("filler ", 10) + "needle" + strings.Repeat(" filler", 40) + ".",
+		},
+	})
+
+	items := index.Search(search.Query{Text: "chips"})
+	require.Len(t, items, 1)
+	assert.Equal(t, &news.Highlight{
+		Title:       "<em>Chip</em> shortage hits car makers",
+		Description: "Production lines are halted &lt;again&gt; as the <em>chip</em> shortage continues.",
+	}, items[0].Highlight)
+
+	items = index.Search(search.Query{Text: "needle"})
+	require.Len(t, items, 1)
+	assert.Equal(t, &news.Highlight{
+		Description: "…" + strings.Repeat("filler ", 5) + "<em>needle</em>" + strings.Repeat(" filler", 24) + "…",
+	}, items[0].Highlight)
+}
+
+func TestIndex_Index(t *testing.T) {
+	now := time.Now()
+
+	index, err := search.New(search.WithMaxItems(2))
+	require.NoError(t, err)
+
+	item := news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "1", Title: "Budget announced", DateTime: now}
+	index.Index([]news.Item{item})
+
+	// the same item in another category is merged
+	technology := item
+	technology.Category = news.CategoryTechnology
+	index.Index([]news.Item{technology})
+
+	items := index.Search(search.Query{Text: "budget"})
+	require.Len(t, items, 1)
+	assert.Empty(t, items[0].Category)
+	assert.Equal(t, []news.Category{news.CategoryUK, news.CategoryTechnology}, items[0].Categories)
+
+	// an updated item replaces the indexed one
+	updated := item
+	updated.Title = "Budget delayed"
+	index.Index([]news.Item{updated})
+
+	assert.Empty(t, index.Search(search.Query{Text: "announced"}))
+	assert.Len(t, index.Search(search.Query{Text: "delayed"}), 1)
+
+	// the oldest items are evicted once the index is full
+	index.Index([]news.Item{
+		{Provider: news.ProviderBBC, GUID: "2", Title: "Budget reaction", DateTime: now.Add(time.Hour)},
+		{Provider: news.ProviderBBC, GUID: "3", Title: "Budget explained", DateTime: now.Add(2 * time.Hour)},
+	})
+
+	assert.Equal(t, []string{"Budget explained", "Budget reaction"}, func() []string {
+		var titles []string
+		for _, i := range index.Search(search.Query{Text: "budget"}) {
+			titles = append(titles, i.Title)
+		}
+		return titles
+	}())
+}
@@ -0,0 +1,218 @@
+package search
+
+// step2Suffixes and step3Suffixes map the suffixes removed by steps 2 and 3 of the
+// Porter stemmer to their replacements. Where one suffix ends another, the longer
+// suffix comes first, as only the longest matching suffix is considered.
+var (
+	step2Suffixes = []suffix{
+		{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+		{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+		{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+		{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+		{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	}
+	step3Suffixes = []suffix{
+		{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+		{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+	}
+	step4Suffixes = []string{
+		"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+		"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+	}
+)
+
+type suffix struct {
+	suffix      string
+	replacement string
+}
+
+// stem returns the stem of a lower cased English word, using the Porter stemmer so
+// that different forms of a word, e.g. "flood", "floods" and "flooding", match.
+// Words that aren't ASCII are returned as they are.
+func stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	w := []byte(word)
+	w = step1a(w)
+	w = step1b(w)
+	w = step1c(w)
+	w = replaceSuffix(w, step2Suffixes)
+	w = replaceSuffix(w, step3Suffixes)
+	w = step4(w)
+	w = step5(w)
+
+	return string(w)
+}
+
+// step1a removes plurals.
+func step1a(w []byte) []byte {
+	switch {
+	case hasSuffix(w, "sses"), hasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case hasSuffix(w, "ss"):
+		return w
+	case hasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// step1b removes past participles, tidying up the stem that's left.
+func step1b(w []byte) []byte {
+	if hasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem []byte
+	switch {
+	case hasSuffix(w, "ed") && hasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case hasSuffix(w, "ing") && hasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case hasSuffix(stem, "at"), hasSuffix(stem, "bl"), hasSuffix(stem, "iz"):
+		return append(stem, 'e')
+	case endsDoubleConsonant(stem):
+		if last := stem[len(stem)-1]; last != 'l' && last != 's' && last != 'z' {
+			return stem[:len(stem)-1]
+		}
+	case measure(stem) == 1 && endsCVC(stem):
+		return append(stem, 'e')
+	}
+	return stem
+}
+
+// step1c turns a final y into an i when there's another vowel.
+func step1c(w []byte) []byte {
+	if hasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		w[len(w)-1] = 'i'
+	}
+	return w
+}
+
+// step4 removes suffixes from stems with more than one vowel-consonant sequence.
+func step4(w []byte) []byte {
+	for _, s := range step4Suffixes {
+		if !hasSuffix(w, s) {
+			continue
+		}
+
+		stem := w[:len(w)-len(s)]
+		if measure(stem) <= 1 {
+			return w
+		}
+		if s == "ion" && !hasSuffix(stem, "s") && !hasSuffix(stem, "t") {
+			return w
+		}
+		return stem
+	}
+	return w
+}
+
+// step5 removes a final e and reduces a final double l.
+func step5(w []byte) []byte {
+	if hasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := measure(stem); m > 1 || m == 1 && !endsCVC(stem) {
+			w = stem
+		}
+	}
+
+	if hasSuffix(w, "ll") && measure(w) > 1 {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// replaceSuffix replaces the longest of suffixes that w ends with, as long as the
+// stem has a vowel-consonant sequence.
+func replaceSuffix(w []byte, suffixes []suffix) []byte {
+	for _, s := range suffixes {
+		if !hasSuffix(w, s.suffix) {
+			continue
+		}
+
+		stem := w[:len(w)-len(s.suffix)]
+		if measure(stem) == 0 {
+			return w
+		}
+		return append(stem, s.replacement...)
+	}
+	return w
+}
+
+func hasSuffix(w []byte, suffix string) bool {
+	return len(w) >= len(suffix) && string(w[len(w)-len(suffix):]) == suffix
+}
+
+// consonant reports whether the letter at i is a consonant. A y is a consonant at
+// the start of a word or after a vowel.
+func consonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !consonant(w, i-1)
+	}
+	return true
+}
+
+// measure returns the number of vowel-consonant sequences in w.
+func measure(w []byte) int {
+	var m, i int
+	for i < len(w) && consonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !consonant(w, i) {
+			i++
+		}
+		if i == len(w) {
+			break
+		}
+		for i < len(w) && consonant(w, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func hasVowel(w []byte) bool {
+	for i := range w {
+		if !consonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func endsDoubleConsonant(w []byte) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && consonant(w, n-1)
+}
+
+// endsCVC reports whether w ends consonant-vowel-consonant, where the last consonant
+// isn't a w, x or y, e.g. "hop" but not "snow".
+func endsCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !consonant(w, n-3) || consonant(w, n-2) || !consonant(w, n-1) {
+		return false
+	}
+	last := w[n-1]
+	return last != 'w' && last != 'x' && last != 'y'
+}
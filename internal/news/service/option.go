@@ -1,17 +1,73 @@
 package news
 
-import "github.com/cshep4/news-api/internal/news"
+import (
+	"github.com/jonboulle/clockwork"
 
-type option func(*service)
+	"github.com/cshep4/news-api/internal/news"
+)
 
-func WithProvider(key news.Provider, provider Provider) option {
+type Option func(*service)
+
+// WithProvider registers a provider under key. If categories are specified, the
+// provider is only used for those categories, otherwise it serves all categories.
+func WithProvider(key news.Provider, provider Provider, categories ...news.Category) Option {
 	return func(s *service) {
 		s.providers[key] = provider
+
+		if len(categories) == 0 {
+			delete(s.providerCategories, key)
+			return
+		}
+
+		s.providerCategories[key] = make(map[news.Category]struct{})
+		for _, c := range categories {
+			s.providerCategories[key][c] = struct{}{}
+		}
+	}
+}
+
+// WithConcurrency sets the max number of feeds that are retrieved concurrently
+// for a request, defaulting to 10.
+func WithConcurrency(concurrency int) Option {
+	return func(s *service) {
+		s.concurrency = concurrency
+	}
+}
+
+func WithCategory(category news.Category) Option {
+	return func(s *service) {
+		s.categories[category] = struct{}{}
+	}
+}
+
+// WithArchive sets the archive that items no longer in the live feeds are read from
+// when a query asks for history.
+func WithArchive(archive Archive) Option {
+	return func(s *service) {
+		s.archive = archive
+	}
+}
+
+// WithClusterer sets the clusterer used to group items about the same story,
+// defaulting to a clusterer with the default options.
+func WithClusterer(clusterer Clusterer) Option {
+	return func(s *service) {
+		s.clusterer = clusterer
+	}
+}
+
+// WithIndex sets the index that items are searched in, defaulting to an index with
+// the default options.
+func WithIndex(index Index) Option {
+	return func(s *service) {
+		s.index = index
 	}
 }
 
-func WithCategory(category news.Category) option {
+// WithClock sets the clock that the max age of items is measured from, defaulting to
+// the real clock.
+func WithClock(clock clockwork.Clock) Option {
 	return func(s *service) {
-		s.categories[category] = struct {}{}
+		s.clock = clock
 	}
 }
@@ -2,145 +2,838 @@ package news
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
+	"sync"
+	"time"
 
+	"github.com/jonboulle/clockwork"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/cshep4/news-api/internal/log"
 	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/news/archive"
+	"github.com/cshep4/news-api/internal/news/cluster"
+	"github.com/cshep4/news-api/internal/news/search"
 )
 
+const defaultConcurrency = 10
+
 type (
 	Provider interface {
 		GetFeed(ctx context.Context, category news.Category) (*news.Feed, error)
 	}
 
+	Archive interface {
+		Items(provider news.Provider, category news.Category, query archive.Query) ([]news.Item, error)
+	}
+
+	Clusterer interface {
+		Cluster(items []news.Item) [][]int
+	}
+
+	Index interface {
+		Index(items []news.Item)
+		Search(query search.Query) []news.Item
+	}
+
 	Cache interface {
 		Get(provider news.Provider, category news.Category) (*news.Feed, bool)
+		GetStale(provider news.Provider, category news.Category) (*news.Feed, bool)
 		Store(provider news.Provider, category news.Category, feed news.Feed)
 	}
 
 	service struct {
-		cache      Cache
-		providers  map[news.Provider]Provider
-		categories map[news.Category]struct{}
+		cache              Cache
+		archive            Archive
+		clusterer          Clusterer
+		index              Index
+		clock              clockwork.Clock
+		providers          map[news.Provider]Provider
+		providerCategories map[news.Provider]map[news.Category]struct{}
+		categories         map[news.Category]struct{}
+		concurrency        int
+		// flights coalesces concurrent cache misses for the same provider and category.
+		flights singleflight.Group
+
+		// indexed holds when the last feed indexed for each provider and category was
+		// fetched, so that a feed is only indexed again once it's been fetched again.
+		indexedMutex sync.Mutex
+		indexed      map[feedKey]time.Time
+	}
+
+	// feedKey identifies the feed of a provider and category.
+	feedKey struct {
+		provider news.Provider
+		category news.Category
+	}
+
+	// byDate sorts items newest first, and then by their IDs.
+	byDate struct {
+		items []news.Item
+		ids   []string
+	}
+
+	// detachedContext carries the values of a context without its cancellation or deadline.
+	detachedContext struct {
+		context.Context
+	}
+
+	// result is the outcome of getting the feed for a source.
+	result struct {
+		feed   *news.Feed
+		cached bool
+		stale  bool
+		err    error
 	}
 )
 
-func New(cache Cache, opts ...option) (*service, error) {
+func New(cache Cache, opts ...Option) (*service, error) {
 	if cache == nil {
 		return nil, news.InvalidParameterError{Parameter: "cache"}
 	}
 
 	s := &service{
-		cache:      cache,
-		providers:  make(map[news.Provider]Provider),
-		categories: make(map[news.Category]struct{}),
+		cache:              cache,
+		providers:          make(map[news.Provider]Provider),
+		providerCategories: make(map[news.Provider]map[news.Category]struct{}),
+		categories:         make(map[news.Category]struct{}),
+		concurrency:        defaultConcurrency,
+		clock:              clockwork.NewRealClock(),
+		indexed:            make(map[feedKey]time.Time),
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
+	switch {
+	case s.concurrency <= 0:
+		return nil, news.InvalidParameterError{Parameter: "concurrency"}
+	case s.clock == nil:
+		return nil, news.InvalidParameterError{Parameter: "clock"}
+	}
+
+	if s.clusterer == nil {
+		clusterer, err := cluster.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create clusterer: %w", err)
+		}
+		s.clusterer = clusterer
+	}
+
+	if s.index == nil {
+		index, err := search.New()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create search index: %w", err)
+		}
+		s.index = index
+	}
+
 	return s, nil
 }
 
-func (s *service) GetFeedByCategory(ctx context.Context, provider news.Provider, category news.Category, offset, limit int) (*news.FeedResponse, error) {
+func (s *service) GetFeedByCategory(ctx context.Context, provider news.Provider, category news.Category, query news.Query) (*news.FeedResponse, error) {
 	if _, ok := s.categories[category]; !ok {
 		return nil, news.ErrCategoryNotFound
 	}
 
-	items, err := s.getFeeds(ctx, provider, category)
+	if provider != news.ProviderAll && len(query.Providers) == 0 && !s.supports(provider, category) {
+		return nil, news.ErrCategoryNotFound
+	}
+
+	sources, err := s.sources(provider, []news.Category{category}, query)
 	if err != nil {
 		return nil, err
 	}
 
-	sort.Slice(items, func(i, j int) bool {
-		return items[i].DateTime.After(items[j].DateTime)
-	})
+	items, res, err := s.getFeeds(ctx, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.History {
+		items = s.withHistory(ctx, items, res, query)
+	}
+
+	items = s.published(items, query)
+
+	sortItems(items)
+
+	items = s.cluster(items, query.Cluster)
+
+	page, next := s.page(items, query)
 
 	return &news.FeedResponse{
-		Category: category,
-		Provider: provider,
-		Items:    s.paginate(items, offset, limit),
-		Limit:    limit,
-		Offset:   offset,
+		Category:   category,
+		Provider:   provider,
+		Items:      page,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+		Total:      total(items, query),
+		HasMore:    next != "",
+		NextCursor: next,
+		Sources:    res,
+		Degraded:   degraded(res),
 	}, nil
 }
 
-func (s *service) GetFeed(ctx context.Context, provider news.Provider, offset, limit int) (*news.FeedResponse, error) {
-	var items []news.Item
+func (s *service) GetFeed(ctx context.Context, provider news.Provider, query news.Query) (*news.FeedResponse, error) {
+	categories, err := s.selectCategories(query.Categories)
+	if err != nil {
+		return nil, err
+	}
 
-	for c := range s.categories {
-		feed, err := s.getFeeds(ctx, provider, c)
-		if err != nil {
-			return nil, err
-		}
+	sources, err := s.sources(provider, categories, query)
+	if err != nil {
+		return nil, err
+	}
 
-		items = append(items, feed...)
+	items, res, err := s.getFeeds(ctx, sources)
+	if err != nil {
+		return nil, err
 	}
 
-	sort.Slice(items, func(i, j int) bool {
-		return items[i].DateTime.After(items[j].DateTime)
-	})
+	if query.History {
+		items = s.withHistory(ctx, items, res, query)
+	}
+
+	items = s.published(items, query)
+
+	items = mergeCategories(items)
+
+	sortItems(items)
+
+	items = s.cluster(items, query.Cluster)
+
+	page, next := s.page(items, query)
 
 	return &news.FeedResponse{
-		Provider: provider,
-		Items:    s.paginate(items, offset, limit),
-		Limit:    limit,
-		Offset:   offset,
+		Provider:   provider,
+		Items:      page,
+		Limit:      query.Limit,
+		Offset:     query.Offset,
+		Total:      total(items, query),
+		HasMore:    next != "",
+		NextCursor: next,
+		Sources:    res,
+		Degraded:   degraded(res),
 	}, nil
 }
 
-func (s *service) getFeeds(ctx context.Context, provider news.Provider, category news.Category) ([]news.Item, error) {
-	if provider == news.ProviderAll {
-		return s.getAllFeedsForCategory(ctx, category)
+// Search returns the items of provider and category matching query, most relevant
+// first. If category is empty, every category is searched. The feed of each source
+// is got first, so that the index is up to date.
+func (s *service) Search(ctx context.Context, provider news.Provider, category news.Category, query news.SearchQuery) (*news.FeedResponse, error) {
+	selected := query.Categories
+	if category != "" {
+		if provider != news.ProviderAll && len(query.Providers) == 0 && !s.supports(provider, category) {
+			return nil, news.ErrCategoryNotFound
+		}
+		selected = []news.Category{category}
 	}
 
-	feed, err := s.getFeed(ctx, provider, category)
+	categories, err := s.selectCategories(selected)
 	if err != nil {
 		return nil, err
 	}
 
-	return feed.Items, nil
-}
+	sources, err := s.sources(provider, categories, query.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	_, res, err := s.getFeeds(ctx, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the providers and categories of the sources are searched, so that
+	// exclusions apply
+	indexQuery := search.Query{
+		Text:  query.Text,
+		Since: s.since(query.Query),
+		Until: query.Until,
+	}
+	for _, source := range sources {
+		if !hasProvider(indexQuery.Providers, source.Provider) {
+			indexQuery.Providers = append(indexQuery.Providers, source.Provider)
+		}
+		if !hasCategory(indexQuery.Categories, source.Category) {
+			indexQuery.Categories = append(indexQuery.Categories, source.Category)
+		}
+	}
 
-func (s *service) getAllFeedsForCategory(ctx context.Context, category news.Category) ([]news.Item, error) {
 	var items []news.Item
+	if len(sources) > 0 {
+		items = s.index.Search(indexQuery)
+	}
+
+	items = s.cluster(items, query.Cluster)
+
+	page := s.paginate(items, query.Offset, query.Limit)
+
+	return &news.FeedResponse{
+		Category: category,
+		Provider: provider,
+		Items:    page,
+		Limit:    query.Limit,
+		Offset:   query.Offset,
+		Total:    total(items, query.Query),
+		HasMore:  query.Offset+len(page) < len(items),
+		Sources:  res,
+		Degraded: degraded(res),
+	}, nil
+}
+
+// selectCategories returns categories, or every category if it's empty. An error is
+// returned if any of the categories aren't supported.
+func (s *service) selectCategories(categories []news.Category) ([]news.Category, error) {
+	if len(categories) == 0 {
+		categories = make([]news.Category, 0, len(s.categories))
+		for c := range s.categories {
+			categories = append(categories, c)
+		}
+		return categories, nil
+	}
+
+	for _, c := range categories {
+		if _, ok := s.categories[c]; !ok {
+			return nil, news.ErrCategoryNotFound
+		}
+	}
+
+	return categories, nil
+}
+
+// sources returns the provider and category pairs to get feeds for, sorted by
+// provider and category. The providers are those of query, if it has any, or
+// provider. Providers that don't serve a category are skipped, along with the
+// providers and categories excluded by query.
+func (s *service) sources(provider news.Provider, categories []news.Category, query news.Query) ([]news.Source, error) {
+	providers := query.Providers
+	switch {
+	case len(providers) > 0:
+	case provider == news.ProviderAll:
+		providers = make([]news.Provider, 0, len(s.providers))
+		for p := range s.providers {
+			providers = append(providers, p)
+		}
+	default:
+		providers = []news.Provider{provider}
+	}
+
+	for _, p := range providers {
+		if _, ok := s.providers[p]; !ok {
+			return nil, news.ErrProviderNotFound
+		}
+	}
+	for _, p := range query.ExcludeProviders {
+		if _, ok := s.providers[p]; !ok {
+			return nil, news.ErrProviderNotFound
+		}
+	}
+	for _, c := range query.ExcludeCategories {
+		if _, ok := s.categories[c]; !ok {
+			return nil, news.ErrCategoryNotFound
+		}
+	}
+
+	// sources is never nil, so that it's encoded as an empty list when no providers are enabled
+	sources := []news.Source{}
+	for _, p := range providers {
+		if hasProvider(query.ExcludeProviders, p) {
+			continue
+		}
+		for _, c := range categories {
+			if hasCategory(query.ExcludeCategories, c) || !s.supports(p, c) {
+				continue
+			}
+			// a provider or category can be selected more than once
+			if source := (news.Source{Provider: p, Category: c}); !hasSource(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+	}
+
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].Provider != sources[j].Provider {
+			return sources[i].Provider < sources[j].Provider
+		}
+		return sources[i].Category < sources[j].Category
+	})
+
+	return sources, nil
+}
+
+// getFeeds concurrently gets the feed for each source, returning the items of the
+// feeds that were retrieved, in source order, along with the outcome of each source.
+// An error is only returned if every source failed.
+func (s *service) getFeeds(ctx context.Context, sources []news.Source) ([]news.Item, []news.Source, error) {
+	var (
+		results = make([]result, len(sources))
+
+		g   errgroup.Group
+		sem = semaphore.NewWeighted(int64(s.concurrency))
+	)
+	for i := range sources {
+		if err := sem.Acquire(ctx, 1); err != nil {
+			_ = g.Wait()
+			return nil, nil, err
+		}
+
+		i := i
+		g.Go(func() error {
+			defer sem.Release(1)
+
+			results[i] = s.getFeed(ctx, sources[i].Provider, sources[i].Category)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var (
+		items    []news.Item
+		firstErr error
+	)
+	for i, r := range results {
+		source := &sources[i]
+		if r.err != nil {
+			log.Info(ctx, "error_getting_source_feed",
+				log.SafeParam("provider", source.Provider),
+				log.SafeParam("category", source.Category),
+				log.ErrorParam(r.err),
+			)
+
+			source.Status = news.SourceStatusFailed
+			source.Error = errorClass(r.err)
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+
+		source.Status = news.SourceStatusOK
+		source.Cached = r.cached
+		source.Stale = r.stale
+		source.SkippedItems = r.feed.SkippedItems
+		items = append(items, r.feed.Items...)
+	}
+
+	if firstErr != nil && !anySucceeded(sources) {
+		return nil, nil, firstErr
+	}
 
-	for p := range s.providers {
-		feed, err := s.getFeed(ctx, p, category)
+	return items, sources, nil
+}
+
+// sortItems sorts items newest first, and then by ID, so that the order of items
+// published at the same time is stable and a cursor can be used to find its place.
+func sortItems(items []news.Item) {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID()
+	}
+
+	sort.Sort(byDate{items: items, ids: ids})
+}
+
+// published returns the items published in the range selected by query.
+func (s *service) published(items []news.Item, query news.Query) []news.Item {
+	from := s.since(query)
+	if from.IsZero() && query.Until.IsZero() {
+		return items
+	}
+
+	filtered := make([]news.Item, 0, len(items))
+	for _, i := range items {
+		if !from.IsZero() && i.DateTime.Before(from) ||
+			!query.Until.IsZero() && i.DateTime.After(query.Until) {
+			continue
+		}
+		filtered = append(filtered, i)
+	}
+
+	return filtered
+}
+
+// since returns the earliest publish time selected by query, the later of Since and
+// MaxAge before now.
+func (s *service) since(query news.Query) time.Time {
+	if query.MaxAge <= 0 {
+		return query.Since
+	}
+
+	if from := s.clock.Now().Add(-query.MaxAge); from.After(query.Since) {
+		return from
+	}
+	return query.Since
+}
+
+// total returns the number of items matching query, or nil with history, as only the
+// archived items up to the end of the page are read.
+func total(items []news.Item, query news.Query) *int {
+	if query.History {
+		return nil
+	}
+
+	n := len(items)
+	return &n
+}
+
+// mergeCategories merges the items that are in the feeds of several categories, so
+// that each item appears once, listing every category it's in. Items are kept in the
+// order they first appear.
+func mergeCategories(items []news.Item) []news.Item {
+	if len(items) == 0 {
+		return items
+	}
+
+	var (
+		merged = make([]news.Item, 0, len(items))
+		index  = make(map[string]int, len(items))
+	)
+	for _, item := range items {
+		id := item.ID()
+		i, ok := index[id]
+		if !ok {
+			i = len(merged)
+			index[id] = i
+			merged = append(merged, item)
+			merged[i].Category = ""
+		}
+
+		if item.Category != "" && !hasCategory(merged[i].Categories, item.Category) {
+			merged[i].Categories = append(merged[i].Categories, item.Category)
+		}
+	}
+
+	return merged
+}
+
+func hasCategory(categories []news.Category, category news.Category) bool {
+	for _, c := range categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+func hasProvider(providers []news.Provider, provider news.Provider) bool {
+	for _, p := range providers {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSource(sources []news.Source, source news.Source) bool {
+	for _, s := range sources {
+		if s.Provider == source.Provider && s.Category == source.Category {
+			return true
+		}
+	}
+	return false
+}
+
+// cluster groups the items of different providers about the same story. Each item
+// in a cluster is annotated with the cluster ID, and when the items are collapsed
+// only the first item of a cluster is kept, listing the others as alternates.
+func (s *service) cluster(items []news.Item, mode news.ClusterMode) []news.Item {
+	if mode != news.ClusterModeAnnotate && mode != news.ClusterModeCollapse {
+		return items
+	}
+
+	clusters := s.clusterer.Cluster(items)
+	if len(clusters) == 0 {
+		return items
+	}
+
+	collapsed := make(map[int]struct{})
+	for _, c := range clusters {
+		id := clusterID(items, c)
+		for _, i := range c {
+			items[i].ClusterID = id
+		}
+
+		if mode != news.ClusterModeCollapse {
+			continue
+		}
+
+		first := &items[c[0]]
+		for _, i := range c[1:] {
+			first.Alternates = append(first.Alternates, news.Alternate{
+				Provider:   items[i].Provider,
+				Category:   items[i].Category,
+				Categories: items[i].Categories,
+				Title:      items[i].Title,
+				Link:       items[i].Link,
+				DateTime:   items[i].DateTime,
+			})
+			collapsed[i] = struct{}{}
+		}
+	}
+
+	if len(collapsed) == 0 {
+		return items
+	}
+
+	kept := make([]news.Item, 0, len(items)-len(collapsed))
+	for i, item := range items {
+		if _, ok := collapsed[i]; !ok {
+			kept = append(kept, item)
+		}
+	}
+
+	return kept
+}
+
+// clusterID returns the lowest ID of the items in a cluster, so that the cluster ID
+// doesn't depend on the order of the items.
+func clusterID(items []news.Item, cluster []int) string {
+	var id string
+	for _, i := range cluster {
+		if itemID := items[i].ID(); id == "" || itemID < id {
+			id = itemID
+		}
+	}
+	return id
+}
+
+// withHistory adds the archived items of each source to the live items, so that the
+// query can page back past the items in the live feeds. Live items take precedence
+// over archived copies of the same item.
+func (s *service) withHistory(ctx context.Context, items []news.Item, sources []news.Source, query news.Query) []news.Item {
+	if s.archive == nil {
+		return items
+	}
+
+	// every item up to the end of the page could come from a single source, and one
+	// more shows whether there's another page. Items before the cursor and outside
+	// the date range are skipped by the archive, as it's ordered by date, so the limit
+	// only counts the items in range. The archive is never read in full, so the limit
+	// is bounded by MaxHistoryItems.
+	offset := query.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	limit := offset + query.Limit
+	if query.Limit <= 0 || limit > news.MaxHistoryItems {
+		limit = news.MaxHistoryItems
+	}
+	archiveQuery := archive.Query{
+		Cursor: query.Cursor,
+		Since:  s.since(query),
+		Until:  query.Until,
+		Limit:  limit + 1,
+	}
+
+	seen := make(map[string]struct{}, len(items))
+	for _, i := range items {
+		seen[i.ID()+string(i.Category)] = struct{}{}
+	}
+
+	for _, source := range sources {
+		archived, err := s.archive.Items(source.Provider, source.Category, archiveQuery)
+		if err != nil {
+			log.Error(ctx, "error_getting_archived_items",
+				log.SafeParam("provider", source.Provider),
+				log.SafeParam("category", source.Category),
+				log.ErrorParam(err),
+			)
+			continue
+		}
+
+		for _, i := range archived {
+			key := i.ID() + string(i.Category)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			items = append(items, i)
+		}
+	}
+
+	return items
+}
+
+// getFeed returns the cached feed for provider and category, fetching it on a miss.
+// A stale feed is returned straight away while it's refreshed in the background,
+// so it continues to be served until the max stale age if the refresh fails.
+func (s *service) getFeed(ctx context.Context, provider news.Provider, category news.Category) result {
+	if feed, ok := s.cache.Get(provider, category); ok {
+		s.indexFeed(provider, category, feed)
+		return result{feed: feed, cached: true}
+	}
+
+	if feed, ok := s.cache.GetStale(provider, category); ok {
+		s.indexFeed(provider, category, feed)
+		s.refresh(ctx, provider, category)
+		return result{feed: feed, cached: true, stale: true}
+	}
+
+	select {
+	case <-ctx.Done():
+		return result{err: fmt.Errorf("failed to get %s feed from %s: %w", category, provider, ctx.Err())}
+	case res := <-s.fetch(ctx, provider, category):
+		if res.Err != nil {
+			return result{err: fmt.Errorf("failed to get %s feed from %s: %w", category, provider, res.Err)}
+		}
+		return result{feed: res.Val.(*news.Feed)}
+	}
+}
+
+// refresh fetches the feed for provider and category in the background.
+func (s *service) refresh(ctx context.Context, provider news.Provider, category news.Category) {
+	flight := s.fetch(ctx, provider, category)
+
+	go func() {
+		if res := <-flight; res.Err != nil {
+			log.Info(ctx, "error_refreshing_stale_feed",
+				log.SafeParam("provider", provider),
+				log.SafeParam("category", category),
+				log.ErrorParam(res.Err),
+			)
+		}
+	}()
+}
+
+// fetch gets the feed for provider and category from the provider and caches it.
+// Concurrent fetches for the same feed share a single request, which isn't aborted
+// if the context of the caller that started it is cancelled.
+func (s *service) fetch(ctx context.Context, provider news.Provider, category news.Category) <-chan singleflight.Result {
+	// the names are quoted so that the key can't be shared by another provider and category
+	key := fmt.Sprintf("%q", feedKey{provider: provider, category: category})
+
+	return s.flights.DoChan(key, func() (interface{}, error) {
+		feed, err := s.providers[provider].GetFeed(detachedContext{ctx}, category)
 		if err != nil {
 			return nil, err
 		}
-		items = append(items, feed.Items...)
+
+		fetched := *feed
+		fetched.FetchedAt = s.clock.Now()
+		s.cache.Store(provider, category, fetched)
+		s.indexFeed(provider, category, &fetched)
+
+		return &fetched, nil
+	})
+}
+
+// indexFeed indexes the items of the feed for provider and category, unless a feed
+// fetched at the same time or later has been indexed. Indexing takes the index's write
+// lock, so a feed is indexed once rather than on every request, but it's indexed when
+// it's read from the cache as well as when it's fetched, as a shared cache can hold
+// feeds fetched by other instances.
+func (s *service) indexFeed(provider news.Provider, category news.Category, feed *news.Feed) {
+	key := feedKey{provider: provider, category: category}
+
+	s.indexedMutex.Lock()
+	if fetchedAt, ok := s.indexed[key]; ok && !feed.FetchedAt.After(fetchedAt) {
+		s.indexedMutex.Unlock()
+		return
 	}
+	s.indexed[key] = feed.FetchedAt
+	s.indexedMutex.Unlock()
 
-	return items, nil
+	s.index.Index(feed.Items)
 }
 
-func (s *service) getFeed(ctx context.Context, provider news.Provider, category news.Category) (*news.Feed, error) {
-	newsProvider, ok := s.providers[provider]
+// supports reports whether provider serves category.
+func (s *service) supports(provider news.Provider, category news.Category) bool {
+	categories, ok := s.providerCategories[provider]
 	if !ok {
-		return nil, news.ErrProviderNotFound
+		return true
 	}
 
-	feed, ok := s.cache.Get(provider, category)
-	if ok {
-		return feed, nil
+	_, ok = categories[category]
+	return ok
+}
+
+func (b byDate) Len() int { return len(b.items) }
+
+func (b byDate) Less(i, j int) bool {
+	if !b.items[i].DateTime.Equal(b.items[j].DateTime) {
+		return b.items[i].DateTime.After(b.items[j].DateTime)
 	}
+	return b.ids[i] < b.ids[j]
+}
 
-	feed, err := newsProvider.GetFeed(ctx, category)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get %s feed from %s: %w", category, provider, err)
+func (b byDate) Swap(i, j int) {
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+	b.ids[i], b.ids[j] = b.ids[j], b.ids[i]
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+// errorClass classifies err so that clients can tell why a source failed
+// without exposing the details of the error.
+func errorClass(err error) news.ErrorClass {
+	var timeout interface{ Timeout() bool }
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &timeout) && timeout.Timeout():
+		return news.ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return news.ErrorClassCanceled
+	case errors.Is(err, news.ErrProviderUnavailable):
+		return news.ErrorClassUnavailable
+	}
+
+	return news.ErrorClassUpstream
+}
+
+func anySucceeded(sources []news.Source) bool {
+	for _, s := range sources {
+		if s.Status == news.SourceStatusOK {
+			return true
+		}
 	}
+	return false
+}
 
-	s.cache.Store(provider, category, *feed)
+func degraded(sources []news.Source) bool {
+	for _, s := range sources {
+		if s.Status != news.SourceStatusOK {
+			return true
+		}
+	}
+	return false
+}
+
+// page returns the page of sorted items selected by query, along with the cursor of
+// the next page if there are more items.
+func (s *service) page(items []news.Item, query news.Query) ([]news.Item, string) {
+	if query.Cursor != nil {
+		start := sort.Search(len(items), func(i int) bool {
+			return query.Cursor.Before(items[i])
+		})
+		items = items[start:]
+	}
+
+	page := s.paginate(items, query.Offset, query.Limit)
+	if query.Limit == 0 || len(page) == 0 || query.Offset+len(page) >= len(items) {
+		return page, ""
+	}
 
-	return feed, nil
+	return page, news.NewCursor(page[len(page)-1]).String()
 }
 
 func (s *service) paginate(items []news.Item, offset, limit int) []news.Item {
-	if offset > len(items) {
+	switch {
+	case offset < 0:
+		offset = 0
+	case offset > len(items):
 		offset = len(items)
 	}
 
@@ -1,3 +0,0 @@
-package news
-
-type Option = option
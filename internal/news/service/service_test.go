@@ -3,17 +3,27 @@ package news_test
 import (
 	"context"
 	"errors"
-	provider_mock "github.com/cshep4/news-api/internal/mock/provider"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	archive_mock "github.com/cshep4/news-api/internal/mock/archive"
 	"github.com/cshep4/news-api/internal/mock/cache"
+	provider_mock "github.com/cshep4/news-api/internal/mock/provider"
 	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/news/archive"
 	"github.com/cshep4/news-api/internal/news/handler/http"
+	"github.com/cshep4/news-api/internal/news/search"
 	service "github.com/cshep4/news-api/internal/news/service"
 )
 
@@ -21,10 +31,26 @@ type testError string
 
 func (e testError) Error() string { return string(e) }
 
+// countingIndex counts the items that are indexed.
+type countingIndex struct {
+	mutex sync.Mutex
+	items int
+}
+
+func (i *countingIndex) Index(items []news.Item) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.items += len(items)
+}
+
+func (i *countingIndex) Search(search.Query) []news.Item { return nil }
+
 func TestNew_Error(t *testing.T) {
 	testCases := []struct {
 		name                   string
 		cache                  service.Cache
+		opts                   []service.Option
 		expectedErrorParameter string
 	}{
 		{
@@ -32,11 +58,23 @@ func TestNew_Error(t *testing.T) {
 			cache:                  nil,
 			expectedErrorParameter: "cache",
 		},
+		{
+			name:                   "concurrency is invalid",
+			cache:                  cache_mock.NewMockCache(nil),
+			opts:                   []service.Option{service.WithConcurrency(0)},
+			expectedErrorParameter: "concurrency",
+		},
+		{
+			name:                   "clock is empty",
+			cache:                  cache_mock.NewMockCache(nil),
+			opts:                   []service.Option{service.WithClock(nil)},
+			expectedErrorParameter: "clock",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			service, err := service.New(tc.cache)
+			service, err := service.New(tc.cache, tc.opts...)
 			require.Error(t, err)
 			require.Nil(t, service)
 
@@ -107,8 +145,13 @@ func TestService_GetFeed_Error(t *testing.T) {
 				Return(nil, false).
 				Times(tc.mockTimes)
 
+			cache.EXPECT().
+				GetStale(tc.provider, news.CategoryUK).
+				Return(nil, false).
+				Times(tc.mockTimes)
+
 			provider.EXPECT().
-				GetFeed(ctx, news.CategoryUK).
+				GetFeed(gomock.Any(), news.CategoryUK).
 				Return(nil, tc.getFeedErr).
 				Times(tc.mockTimes)
 
@@ -118,7 +161,7 @@ func TestService_GetFeed_Error(t *testing.T) {
 			)
 			require.NoError(t, err)
 
-			res, err := service.GetFeed(ctx, tc.provider, 0, 0)
+			res, err := service.GetFeed(ctx, tc.provider, news.Query{})
 			require.Error(t, err)
 			require.Nil(t, res)
 
@@ -132,8 +175,8 @@ func TestService_GetFeed_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	var (
-		item1 = news.Item{DateTime: time.Now().Add(time.Second)}
-		item2 = news.Item{DateTime: time.Now()}
+		item1 = news.Item{Provider: news.ProviderBBC, GUID: "1", DateTime: time.Now().Add(time.Second)}
+		item2 = news.Item{Provider: news.ProviderSky, GUID: "2", DateTime: time.Now()}
 	)
 
 	type provider struct {
@@ -174,6 +217,11 @@ func TestService_GetFeed_Success(t *testing.T) {
 			expectedResult: &news.FeedResponse{
 				Provider: news.ProviderAll,
 				Items:    []news.Item{item1, item2},
+				Total:    total(2),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+				},
 			},
 		},
 		{
@@ -198,6 +246,11 @@ func TestService_GetFeed_Success(t *testing.T) {
 			expectedResult: &news.FeedResponse{
 				Provider: news.ProviderAll,
 				Items:    []news.Item{item1, item2},
+				Total:    total(2),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusOK},
+				},
 			},
 		},
 		{
@@ -208,6 +261,8 @@ func TestService_GetFeed_Success(t *testing.T) {
 			cacheFeed: false,
 			expectedResult: &news.FeedResponse{
 				Provider: news.ProviderAll,
+				Sources:  []news.Source{},
+				Total:    total(0),
 			},
 		},
 		{
@@ -231,9 +286,16 @@ func TestService_GetFeed_Success(t *testing.T) {
 			cacheFeed: false,
 			limit:     1,
 			expectedResult: &news.FeedResponse{
-				Provider: news.ProviderAll,
-				Items:    []news.Item{item1},
-				Limit:    1,
+				Provider:   news.ProviderAll,
+				Items:      []news.Item{item1},
+				Limit:      1,
+				Total:      total(2),
+				HasMore:    true,
+				NextCursor: news.NewCursor(item1).String(),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+				},
 			},
 		},
 		{
@@ -260,6 +322,11 @@ func TestService_GetFeed_Success(t *testing.T) {
 				Provider: news.ProviderAll,
 				Items:    []news.Item{item2},
 				Offset:   1,
+				Total:    total(2),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+				},
 			},
 		},
 	}
@@ -267,9 +334,11 @@ func TestService_GetFeed_Success(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			cache := cache_mock.NewMockCache(ctrl)
+			clock := clockwork.NewFakeClock()
 
 			opts := []service.Option{
 				service.WithCategory(tc.category),
+				service.WithClock(clock),
 			}
 
 			for _, p := range tc.providers {
@@ -278,8 +347,9 @@ func TestService_GetFeed_Success(t *testing.T) {
 				cache.EXPECT().Get(p.name, tc.category).Return(feed, p.cached)
 
 				if !p.cached {
-					p.provider.EXPECT().GetFeed(ctx, tc.category).Return(feed, nil)
-					cache.EXPECT().Store(p.name, tc.category, *feed)
+					cache.EXPECT().GetStale(p.name, tc.category).Return(nil, false)
+					p.provider.EXPECT().GetFeed(gomock.Any(), tc.category).Return(feed, nil)
+					cache.EXPECT().Store(p.name, tc.category, fetchedAt(*feed, clock.Now()))
 				}
 
 				opts = append(opts, service.WithProvider(p.name, p.provider))
@@ -288,7 +358,7 @@ func TestService_GetFeed_Success(t *testing.T) {
 			service, err := service.New(cache, opts...)
 			require.NoError(t, err)
 
-			res, err := service.GetFeed(ctx, tc.provider, tc.offset, tc.limit)
+			res, err := service.GetFeed(ctx, tc.provider, news.Query{Offset: tc.offset, Limit: tc.limit})
 			require.NoError(t, err)
 
 			assert.Equal(t, tc.expectedResult, res)
@@ -296,37 +366,28 @@ func TestService_GetFeed_Success(t *testing.T) {
 	}
 }
 
-func TestService_GetFeedByCategory_Error(t *testing.T) {
-	const testErr = testError("error")
+func TestService_GetFeed_PartialResults(t *testing.T) {
+	item := news.Item{Title: "item"}
+
 	testCases := []struct {
-		name        string
-		provider    news.Provider
-		category    news.Category
-		mockTimes   int
-		getFeedErr  error
-		expectedErr error
+		name          string
+		getFeedErr    error
+		expectedError news.ErrorClass
 	}{
 		{
-			name:        "invalid provider",
-			provider:    "invalid provider",
-			category:    news.CategoryUK,
-			mockTimes:   0,
-			expectedErr: news.ErrProviderNotFound,
+			name:          "timeout",
+			getFeedErr:    context.DeadlineExceeded,
+			expectedError: news.ErrorClassTimeout,
 		},
 		{
-			name:        "invalid category",
-			provider:    news.ProviderAll,
-			category:    "invalid category",
-			mockTimes:   0,
-			expectedErr: news.ErrCategoryNotFound,
+			name:          "provider unavailable",
+			getFeedErr:    fmt.Errorf("circuit breaker is open: %w", news.ErrProviderUnavailable),
+			expectedError: news.ErrorClassUnavailable,
 		},
 		{
-			name:        "error getting feed",
-			provider:    news.ProviderAll,
-			category:    news.CategoryUK,
-			mockTimes:   1,
-			getFeedErr:  testErr,
-			expectedErr: testErr,
+			name:          "upstream error",
+			getFeedErr:    testError("error"),
+			expectedError: news.ErrorClassUpstream,
 		},
 	}
 
@@ -336,203 +397,1648 @@ func TestService_GetFeedByCategory_Error(t *testing.T) {
 			defer ctrl.Finish()
 
 			cache := cache_mock.NewMockCache(ctrl)
-			provider := provider_mock.NewMockProvider(ctrl)
+			bbc := provider_mock.NewMockProvider(ctrl)
+			sky := provider_mock.NewMockProvider(ctrl)
 
-			cache.EXPECT().
-				Get(tc.provider, news.CategoryUK).
-				Return(nil, false).
-				Times(tc.mockTimes)
-
-			provider.EXPECT().
-				GetFeed(ctx, news.CategoryUK).
-				Return(nil, tc.getFeedErr).
-				Times(tc.mockTimes)
+			cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: []news.Item{item}, SkippedItems: 2}, true)
+			cache.EXPECT().Get(news.ProviderSky, news.CategoryUK).Return(nil, false)
+			cache.EXPECT().GetStale(news.ProviderSky, news.CategoryUK).Return(nil, false)
+			sky.EXPECT().GetFeed(gomock.Any(), news.CategoryUK).Return(nil, tc.getFeedErr)
 
 			service, err := service.New(cache,
-				service.WithProvider(news.ProviderAll, provider),
+				service.WithProvider(news.ProviderBBC, bbc),
+				service.WithProvider(news.ProviderSky, sky),
 				service.WithCategory(news.CategoryUK),
 			)
 			require.NoError(t, err)
 
-			res, err := service.GetFeedByCategory(ctx, tc.provider, tc.category, 0, 0)
-			require.Error(t, err)
-			require.Nil(t, res)
+			res, err := service.GetFeed(ctx, news.ProviderAll, news.Query{})
+			require.NoError(t, err)
 
-			assert.True(t, errors.Is(err, tc.expectedErr))
+			assert.Equal(t, &news.FeedResponse{
+				Provider: news.ProviderAll,
+				Items:    []news.Item{item},
+				Total:    total(1),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true, SkippedItems: 2},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusFailed, Error: tc.expectedError},
+				},
+				Degraded: true,
+			}, res)
 		})
 	}
 }
 
-func TestService_GetFeedByCategory_Success(t *testing.T) {
+func TestService_GetFeed_MergeCategories(t *testing.T) {
 	ctrl, ctx := gomock.WithContext(context.Background(), t)
 	defer ctrl.Finish()
 
+	now := time.Now()
+
 	var (
-		item1 = news.Item{DateTime: time.Now().Add(time.Second)}
-		item2 = news.Item{DateTime: time.Now()}
+		both = news.Item{Provider: news.ProviderBBC, GUID: "1", Title: "both", DateTime: now}
+		uk   = news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "2", Title: "uk", DateTime: now.Add(-time.Hour)}
 	)
 
-	type provider struct {
-		name     news.Provider
-		provider *provider_mock.MockProvider
-		items    []news.Item
-		cached   bool
+	ukBoth, technologyBoth := both, both
+	ukBoth.Category = news.CategoryUK
+	technologyBoth.Category = news.CategoryTechnology
+
+	cache := cache_mock.NewMockCache(ctrl)
+	cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: []news.Item{ukBoth, uk}}, true).Times(2)
+	cache.EXPECT().Get(news.ProviderBBC, news.CategoryTechnology).Return(&news.Feed{Items: []news.Item{technologyBoth}}, true).Times(2)
+
+	service, err := service.New(cache,
+		service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+		service.WithCategory(news.CategoryUK),
+		service.WithCategory(news.CategoryTechnology),
+	)
+	require.NoError(t, err)
+
+	res, err := service.GetFeed(ctx, news.ProviderBBC, news.Query{Offset: 1})
+	require.NoError(t, err)
+
+	uk.Category = ""
+	uk.Categories = []news.Category{news.CategoryUK}
+	assert.Equal(t, []news.Item{uk}, res.Items)
+
+	res, err = service.GetFeed(ctx, news.ProviderBBC, news.Query{Limit: 1})
+	require.NoError(t, err)
+
+	both.Categories = []news.Category{news.CategoryTechnology, news.CategoryUK}
+	assert.Equal(t, []news.Item{both}, res.Items)
+}
+
+func TestService_GetFeed_Selection(t *testing.T) {
+	const (
+		providerBlog     news.Provider = "blog"
+		categoryBusiness news.Category = "business"
+	)
+
+	source := func(provider news.Provider, category news.Category) news.Source {
+		return news.Source{Provider: provider, Category: category, Status: news.SourceStatusOK, Cached: true}
 	}
+
 	testCases := []struct {
-		name           string
-		providers      []provider
-		category       news.Category
-		provider       news.Provider
-		limit          int
-		offset         int
-		cacheFeed      bool
-		expectedResult *news.FeedResponse
+		name            string
+		provider        news.Provider
+		query           news.Query
+		expectedSources []news.Source
+		expectedErr     error
 	}{
 		{
-			name: "return cached data",
-			providers: []provider{
-				{
-					name:     news.ProviderBBC,
-					provider: provider_mock.NewMockProvider(ctrl),
-					cached:   true,
-					items:    []news.Item{item1},
-				},
-				{
-					name:     news.ProviderSky,
-					provider: provider_mock.NewMockProvider(ctrl),
-					cached:   true,
-					items:    []news.Item{item2},
-				},
-			},
-			category:  news.CategoryUK,
-			provider:  news.ProviderAll,
-			cacheFeed: false,
-			expectedResult: &news.FeedResponse{
-				Category: news.CategoryUK,
-				Provider: news.ProviderAll,
-				Items:    []news.Item{item1, item2},
+			name:     "several providers",
+			provider: news.ProviderAll,
+			query:    news.Query{Providers: []news.Provider{news.ProviderSky, news.ProviderBBC}},
+			expectedSources: []news.Source{
+				source(news.ProviderBBC, categoryBusiness),
+				source(news.ProviderBBC, news.CategoryTechnology),
+				source(news.ProviderBBC, news.CategoryUK),
+				source(news.ProviderSky, news.CategoryUK),
 			},
 		},
 		{
-			name: "retrieve data",
-			providers: []provider{
-				{
-					name:     news.ProviderBBC,
-					provider: provider_mock.NewMockProvider(ctrl),
-					cached:   false,
-					items:    []news.Item{item1},
-				},
-				{
-					name:     news.ProviderSky,
-					provider: provider_mock.NewMockProvider(ctrl),
-					cached:   false,
-					items:    []news.Item{item2},
-				},
-			},
-			category:  news.CategoryUK,
-			provider:  news.ProviderAll,
-			cacheFeed: false,
-			expectedResult: &news.FeedResponse{
-				Category: news.CategoryUK,
-				Provider: news.ProviderAll,
-				Items:    []news.Item{item1, item2},
+			name:     "several categories",
+			provider: news.ProviderAll,
+			query:    news.Query{Categories: []news.Category{news.CategoryUK, news.CategoryTechnology}},
+			expectedSources: []news.Source{
+				source(news.ProviderBBC, news.CategoryTechnology),
+				source(news.ProviderBBC, news.CategoryUK),
+				source(providerBlog, news.CategoryTechnology),
+				source(news.ProviderSky, news.CategoryUK),
 			},
 		},
 		{
-			name:      "no providers enabled",
-			providers: []provider{},
-			category:  news.CategoryUK,
-			provider:  news.ProviderAll,
-			cacheFeed: false,
-			expectedResult: &news.FeedResponse{
-				Category: news.CategoryUK,
-				Provider: news.ProviderAll,
+			name:     "excluded provider and category",
+			provider: news.ProviderAll,
+			query: news.Query{
+				ExcludeProviders:  []news.Provider{news.ProviderBBC},
+				ExcludeCategories: []news.Category{news.CategoryUK},
+			},
+			expectedSources: []news.Source{
+				source(providerBlog, news.CategoryTechnology),
 			},
 		},
 		{
-			name: "limit results",
-			providers: []provider{
-				{
-					name:     news.ProviderBBC,
-					provider: provider_mock.NewMockProvider(ctrl),
-					cached:   true,
-					items:    []news.Item{item1},
-				},
-				{
-					name:     news.ProviderSky,
-					provider: provider_mock.NewMockProvider(ctrl),
-					cached:   true,
-					items:    []news.Item{item2},
-				},
+			name:     "excluded from one provider",
+			provider: news.ProviderBBC,
+			query: news.Query{
+				Categories:        []news.Category{news.CategoryUK, news.CategoryTechnology},
+				ExcludeCategories: []news.Category{news.CategoryTechnology},
 			},
-			category:  news.CategoryUK,
-			provider:  news.ProviderAll,
-			cacheFeed: false,
-			limit:     1,
-			expectedResult: &news.FeedResponse{
-				Category: news.CategoryUK,
-				Provider: news.ProviderAll,
-				Items:    []news.Item{item1},
-				Limit:    1,
+			expectedSources: []news.Source{
+				source(news.ProviderBBC, news.CategoryUK),
 			},
 		},
 		{
-			name: "offset results",
-			providers: []provider{
-				{
-					name:     news.ProviderBBC,
-					provider: provider_mock.NewMockProvider(ctrl),
-					cached:   true,
-					items:    []news.Item{item1},
-				},
-				{
-					name:     news.ProviderSky,
-					provider: provider_mock.NewMockProvider(ctrl),
-					cached:   true,
-					items:    []news.Item{item2},
-				},
-			},
-			category:  news.CategoryUK,
-			provider:  news.ProviderAll,
-			cacheFeed: false,
-			offset:    1,
-			expectedResult: &news.FeedResponse{
-				Category: news.CategoryUK,
-				Provider: news.ProviderAll,
-				Items:    []news.Item{item2},
-				Offset:   1,
-			},
+			name:            "everything excluded",
+			provider:        news.ProviderSky,
+			query:           news.Query{ExcludeCategories: []news.Category{news.CategoryUK}},
+			expectedSources: []news.Source{},
+		},
+		{
+			name:        "unknown provider",
+			provider:    news.ProviderAll,
+			query:       news.Query{Providers: []news.Provider{news.ProviderBBC, "invalid provider"}},
+			expectedErr: news.ErrProviderNotFound,
+		},
+		{
+			name:        "unknown category",
+			provider:    news.ProviderAll,
+			query:       news.Query{Categories: []news.Category{news.CategoryUK, "sport"}},
+			expectedErr: news.ErrCategoryNotFound,
+		},
+		{
+			name:        "unknown excluded provider",
+			provider:    news.ProviderAll,
+			query:       news.Query{ExcludeProviders: []news.Provider{"invalid provider"}},
+			expectedErr: news.ErrProviderNotFound,
+		},
+		{
+			name:        "unknown excluded category",
+			provider:    news.ProviderAll,
+			query:       news.Query{ExcludeCategories: []news.Category{"sport"}},
+			expectedErr: news.ErrCategoryNotFound,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
 			cache := cache_mock.NewMockCache(ctrl)
+			for _, s := range tc.expectedSources {
+				cache.EXPECT().Get(s.Provider, s.Category).Return(&news.Feed{}, true)
+			}
 
-			opts := []service.Option{
-				service.WithCategory(tc.category),
+			service, err := service.New(cache,
+				service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+				service.WithProvider(news.ProviderSky, provider_mock.NewMockProvider(ctrl), news.CategoryUK),
+				service.WithProvider(providerBlog, provider_mock.NewMockProvider(ctrl), news.CategoryTechnology),
+				service.WithCategory(news.CategoryUK),
+				service.WithCategory(news.CategoryTechnology),
+				service.WithCategory(categoryBusiness),
+			)
+			require.NoError(t, err)
+
+			res, err := service.GetFeed(ctx, tc.provider, tc.query)
+			if tc.expectedErr != nil {
+				require.Error(t, err)
+				require.Nil(t, res)
+
+				assert.True(t, errors.Is(err, tc.expectedErr))
+				return
 			}
+			require.NoError(t, err)
 
-			for _, p := range tc.providers {
-				feed := &news.Feed{Items: p.items}
+			assert.Equal(t, tc.expectedSources, res.Sources)
+		})
+	}
+}
 
-				cache.EXPECT().Get(p.name, tc.category).Return(feed, p.cached)
+func TestService_GetFeed_Concurrency(t *testing.T) {
+	const concurrency = 2
 
-				if !p.cached {
-					p.provider.EXPECT().GetFeed(ctx, tc.category).Return(feed, nil)
-					cache.EXPECT().Store(p.name, tc.category, *feed)
-				}
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
 
-				opts = append(opts, service.WithProvider(p.name, p.provider))
-			}
+	var (
+		now      = time.Now()
+		inFlight int32
+		maxSeen  int32
+		both     sync.WaitGroup
+		started  = make(chan struct{})
+	)
 
-			service, err := service.New(cache, opts...)
-			require.NoError(t, err)
+	both.Add(2)
+	go func() {
+		both.Wait()
+		close(started)
+	}()
 
-			res, err := service.GetFeedByCategory(ctx, tc.provider, tc.category, tc.offset, tc.limit)
-			require.NoError(t, err)
+	cache := cache_mock.NewMockCache(ctrl)
+	cache.EXPECT().Get(gomock.Any(), news.CategoryUK).Return(nil, false).Times(4)
+	cache.EXPECT().GetStale(gomock.Any(), news.CategoryUK).Return(nil, false).Times(4)
+	cache.EXPECT().Store(gomock.Any(), news.CategoryUK, gomock.Any()).Times(4)
 
-			assert.Equal(t, tc.expectedResult, res)
-		})
+	opts := []service.Option{
+		service.WithCategory(news.CategoryUK),
+		service.WithConcurrency(concurrency),
+	}
+
+	var expectedItems []news.Item
+	for i, name := range []news.Provider{"a", "b", "c", "d"} {
+		name := name
+		item := news.Item{Provider: name, DateTime: now.Add(-time.Duration(i) * time.Second)}
+		expectedItems = append(expectedItems, item)
+
+		provider := provider_mock.NewMockProvider(ctrl)
+		provider.EXPECT().
+			GetFeed(gomock.Any(), news.CategoryUK).
+			DoAndReturn(func(context.Context, news.Category) (*news.Feed, error) {
+				n := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+
+				for {
+					m := atomic.LoadInt32(&maxSeen)
+					if n <= m || atomic.CompareAndSwapInt32(&maxSeen, m, n) {
+						break
+					}
+				}
+
+				// the first two feeds are only returned once both have been requested
+				if name == "a" || name == "b" {
+					both.Done()
+					select {
+					case <-started:
+					case <-time.After(time.Second):
+						t.Error("feeds were not requested concurrently")
+					}
+				}
+
+				return &news.Feed{Items: []news.Item{item}}, nil
+			})
+
+		opts = append(opts, service.WithProvider(name, provider))
 	}
+
+	service, err := service.New(cache, opts...)
+	require.NoError(t, err)
+
+	res, err := service.GetFeed(ctx, news.ProviderAll, news.Query{})
+	require.NoError(t, err)
+
+	assert.Equal(t, expectedItems, res.Items)
+	assert.Equal(t, int32(concurrency), atomic.LoadInt32(&maxSeen))
+}
+
+func TestService_GetFeedByCategory_CoalesceMisses(t *testing.T) {
+	const callers = 3
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	var (
+		feed     = &news.Feed{Items: []news.Item{{Title: "item"}}}
+		clock    = clockwork.NewFakeClock()
+		misses   sync.WaitGroup
+		requests = make(chan struct{})
+		release  = make(chan struct{})
+	)
+	misses.Add(callers)
+
+	cache := cache_mock.NewMockCache(ctrl)
+	cache.EXPECT().
+		Get(news.ProviderBBC, news.CategoryUK).
+		DoAndReturn(func(news.Provider, news.Category) (*news.Feed, bool) {
+			misses.Done()
+			return nil, false
+		}).
+		Times(callers)
+	cache.EXPECT().GetStale(news.ProviderBBC, news.CategoryUK).Return(nil, false).Times(callers)
+	cache.EXPECT().Store(news.ProviderBBC, news.CategoryUK, fetchedAt(*feed, clock.Now()))
+
+	provider := provider_mock.NewMockProvider(ctrl)
+	provider.EXPECT().
+		GetFeed(gomock.Any(), news.CategoryUK).
+		DoAndReturn(func(context.Context, news.Category) (*news.Feed, error) {
+			close(requests)
+			<-release
+			return feed, nil
+		})
+
+	service, err := service.New(cache,
+		service.WithProvider(news.ProviderBBC, provider),
+		service.WithCategory(news.CategoryUK),
+		service.WithClock(clock),
+	)
+	require.NoError(t, err)
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]*news.FeedResponse, callers)
+		errs    = make([]error, callers)
+	)
+	for i := 0; i < callers; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, news.Query{})
+		}()
+	}
+
+	<-requests
+	misses.Wait()
+	// give the callers that missed the cache time to join the in-flight request
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i := 0; i < callers; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, feed.Items, results[i].Items)
+	}
+}
+
+func TestService_GetFeedByCategory_CoalesceMisses_DistinctFeeds(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	// the provider and category names would join into the same key if they weren't quoted
+	feeds := []struct {
+		provider news.Provider
+		category news.Category
+	}{
+		{provider: "a-b", category: "c"},
+		{provider: "a", category: "b-c"},
+	}
+
+	var requests sync.WaitGroup
+	requests.Add(len(feeds))
+	requested := make(chan struct{})
+	go func() {
+		requests.Wait()
+		close(requested)
+	}()
+
+	cache := cache_mock.NewMockCache(ctrl)
+	cache.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, false).Times(len(feeds))
+	cache.EXPECT().GetStale(gomock.Any(), gomock.Any()).Return(nil, false).Times(len(feeds))
+	cache.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Times(len(feeds))
+
+	opts := []service.Option{}
+	for _, f := range feeds {
+		f := f
+
+		provider := provider_mock.NewMockProvider(ctrl)
+		provider.EXPECT().
+			GetFeed(gomock.Any(), f.category).
+			DoAndReturn(func(context.Context, news.Category) (*news.Feed, error) {
+				// both feeds are requested at the same time
+				requests.Done()
+				select {
+				case <-requested:
+				case <-time.After(time.Second):
+				}
+				return &news.Feed{Items: []news.Item{{Provider: f.provider, Category: f.category, GUID: "1"}}}, nil
+			})
+
+		opts = append(opts,
+			service.WithProvider(f.provider, provider, f.category),
+			service.WithCategory(f.category),
+		)
+	}
+
+	service, err := service.New(cache, opts...)
+	require.NoError(t, err)
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]*news.FeedResponse, len(feeds))
+		errs    = make([]error, len(feeds))
+	)
+	for i, f := range feeds {
+		i, f := i, f
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = service.GetFeedByCategory(ctx, f.provider, f.category, news.Query{})
+		}()
+	}
+	wg.Wait()
+
+	for i, f := range feeds {
+		require.NoError(t, errs[i])
+		require.Len(t, results[i].Items, 1)
+		assert.Equal(t, f.provider, results[i].Items[0].Provider)
+	}
+}
+
+func TestService_GetFeedByCategory_CancelledCaller(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		feed     = &news.Feed{Items: []news.Item{{Title: "item"}}}
+		clock    = clockwork.NewFakeClock()
+		requests = make(chan struct{})
+		release  = make(chan struct{})
+		stored   = make(chan struct{})
+	)
+
+	cache := cache_mock.NewMockCache(ctrl)
+	cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(nil, false)
+	cache.EXPECT().GetStale(news.ProviderBBC, news.CategoryUK).Return(nil, false)
+	cache.EXPECT().
+		Store(news.ProviderBBC, news.CategoryUK, fetchedAt(*feed, clock.Now())).
+		Do(func(news.Provider, news.Category, news.Feed) { close(stored) })
+
+	provider := provider_mock.NewMockProvider(ctrl)
+	provider.EXPECT().
+		GetFeed(gomock.Any(), news.CategoryUK).
+		DoAndReturn(func(ctx context.Context, _ news.Category) (*news.Feed, error) {
+			close(requests)
+			<-release
+			assert.NoError(t, ctx.Err())
+			return feed, nil
+		})
+
+	service, err := service.New(cache,
+		service.WithProvider(news.ProviderBBC, provider),
+		service.WithCategory(news.CategoryUK),
+		service.WithClock(clock),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-requests
+		cancel()
+	}()
+
+	res, err := service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, news.Query{})
+	require.Error(t, err)
+	require.Nil(t, res)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	close(release)
+
+	select {
+	case <-stored:
+	case <-time.After(time.Second):
+		t.Fatal("feed was not stored after the caller was cancelled")
+	}
+}
+
+func TestService_GetFeedByCategory_Stale(t *testing.T) {
+	var (
+		staleFeed = &news.Feed{Items: []news.Item{{Title: "stale"}}}
+		freshFeed = &news.Feed{Items: []news.Item{{Title: "fresh"}}}
+		clock     = clockwork.NewFakeClock()
+	)
+
+	testCases := []struct {
+		name       string
+		refreshErr error
+	}{
+		{
+			name: "stale feed served while it's refreshed",
+		},
+		{
+			name:       "stale feed served if refresh fails",
+			refreshErr: testError("error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			refreshed := make(chan struct{})
+
+			cache := cache_mock.NewMockCache(ctrl)
+			cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(nil, false)
+			cache.EXPECT().GetStale(news.ProviderBBC, news.CategoryUK).Return(staleFeed, true)
+
+			provider := provider_mock.NewMockProvider(ctrl)
+			if tc.refreshErr != nil {
+				provider.EXPECT().
+					GetFeed(gomock.Any(), news.CategoryUK).
+					DoAndReturn(func(context.Context, news.Category) (*news.Feed, error) {
+						close(refreshed)
+						return nil, tc.refreshErr
+					})
+			} else {
+				provider.EXPECT().GetFeed(gomock.Any(), news.CategoryUK).Return(freshFeed, nil)
+				cache.EXPECT().
+					Store(news.ProviderBBC, news.CategoryUK, fetchedAt(*freshFeed, clock.Now())).
+					Do(func(news.Provider, news.Category, news.Feed) { close(refreshed) })
+			}
+
+			service, err := service.New(cache,
+				service.WithProvider(news.ProviderBBC, provider),
+				service.WithCategory(news.CategoryUK),
+				service.WithClock(clock),
+			)
+			require.NoError(t, err)
+
+			res, err := service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, news.Query{})
+			require.NoError(t, err)
+
+			assert.Equal(t, &news.FeedResponse{
+				Category: news.CategoryUK,
+				Provider: news.ProviderBBC,
+				Items:    staleFeed.Items,
+				Total:    total(1),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true, Stale: true},
+				},
+			}, res)
+
+			select {
+			case <-refreshed:
+			case <-time.After(time.Second):
+				t.Fatal("stale feed was not refreshed")
+			}
+		})
+	}
+}
+
+func TestService_GetFeedByCategory_History(t *testing.T) {
+	now := time.Now()
+
+	var (
+		live    = news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "1", Title: "live", DateTime: now}
+		updated = news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "1", Title: "archived", DateTime: now}
+		older   = news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "2", Title: "older", DateTime: now.Add(-time.Hour)}
+		oldest  = news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "3", Title: "oldest", DateTime: now.Add(-2 * time.Hour)}
+	)
+
+	olderCursor := news.NewCursor(older)
+
+	testCases := []struct {
+		name          string
+		query         news.Query
+		archiveQuery  archive.Query
+		archiveItems  []news.Item
+		archiveErr    error
+		expectedItems []news.Item
+	}{
+		{
+			name:          "page back past the live feed",
+			query:         news.Query{Offset: 1, Limit: 2, History: true},
+			archiveQuery:  archive.Query{Limit: 4},
+			archiveItems:  []news.Item{updated, older, oldest},
+			expectedItems: []news.Item{older, oldest},
+		},
+		{
+			name:          "page after a cursor",
+			query:         news.Query{Cursor: &olderCursor, Limit: 1, History: true},
+			archiveQuery:  archive.Query{Cursor: &olderCursor, Limit: 2},
+			archiveItems:  []news.Item{oldest},
+			expectedItems: []news.Item{oldest},
+		},
+		{
+			name:          "date range is read from the archive",
+			query:         news.Query{Since: oldest.DateTime, Until: older.DateTime, Limit: 10, History: true},
+			archiveQuery:  archive.Query{Since: oldest.DateTime, Until: older.DateTime, Limit: 11},
+			archiveItems:  []news.Item{older, oldest},
+			expectedItems: []news.Item{older, oldest},
+		},
+		{
+			name:          "history without a limit is bounded",
+			query:         news.Query{History: true},
+			archiveQuery:  archive.Query{Limit: 1001},
+			archiveItems:  []news.Item{updated, older, oldest},
+			expectedItems: []news.Item{live, older, oldest},
+		},
+		{
+			name:          "negative offset is bounded",
+			query:         news.Query{Offset: -1000000, Limit: 10, History: true},
+			archiveQuery:  archive.Query{Limit: 11},
+			archiveItems:  []news.Item{updated, older, oldest},
+			expectedItems: []news.Item{live, older, oldest},
+		},
+		{
+			name:          "history past the max is bounded",
+			query:         news.Query{Offset: 990, Limit: 20, History: true},
+			archiveQuery:  archive.Query{Limit: 1001},
+			archiveItems:  []news.Item{updated, older, oldest},
+			expectedItems: []news.Item{},
+		},
+		{
+			name:          "live items when archive fails",
+			query:         news.Query{History: true, Limit: 10},
+			archiveQuery:  archive.Query{Limit: 11},
+			archiveErr:    testError("error"),
+			expectedItems: []news.Item{live},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			cache := cache_mock.NewMockCache(ctrl)
+			cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: []news.Item{live}}, true)
+
+			archive := archive_mock.NewMockArchive(ctrl)
+			archive.EXPECT().Items(news.ProviderBBC, news.CategoryUK, tc.archiveQuery).Return(tc.archiveItems, tc.archiveErr)
+
+			service, err := service.New(cache,
+				service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+				service.WithCategory(news.CategoryUK),
+				service.WithArchive(archive),
+			)
+			require.NoError(t, err)
+
+			res, err := service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, tc.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedItems, res.Items)
+		})
+	}
+}
+
+func total(n int) *int {
+	return &n
+}
+
+// fetchedAt returns feed as it's stored when it's fetched at dateTime.
+func fetchedAt(feed news.Feed, dateTime time.Time) news.Feed {
+	feed.FetchedAt = dateTime
+	return feed
+}
+
+// newArchive returns an archive of items, stored in a temporary file.
+func newArchive(t *testing.T, items []news.Item) service.Archive {
+	dir, err := ioutil.TempDir("", "archive")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	a, err := archive.New(filepath.Join(dir, "archive.db"), clockwork.NewFakeClock())
+	require.NoError(t, err)
+	t.Cleanup(func() { a.Close() })
+
+	require.NoError(t, a.Record(items))
+
+	return a
+}
+
+func TestService_GetFeedByCategory_HistoryCursor(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// the live feed has the newest of the archived items
+	items := make([]news.Item, 100)
+	for i := range items {
+		items[i] = news.Item{
+			Provider: news.ProviderBBC,
+			Category: news.CategoryUK,
+			GUID:     fmt.Sprintf("%d", i),
+			DateTime: now.Add(-time.Duration(i) * time.Hour),
+		}
+	}
+
+	cache := cache_mock.NewMockCache(ctrl)
+	cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: items[:10]}, true).AnyTimes()
+
+	service, err := service.New(cache,
+		service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+		service.WithCategory(news.CategoryUK),
+		service.WithArchive(newArchive(t, items)),
+	)
+	require.NoError(t, err)
+
+	var (
+		got   []news.Item
+		query = news.Query{Limit: 20, History: true}
+	)
+	for pages := 0; pages < 10; pages++ {
+		res, err := service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, query)
+		require.NoError(t, err)
+
+		got = append(got, res.Items...)
+		if res.NextCursor == "" {
+			break
+		}
+
+		cursor, err := news.ParseCursor(res.NextCursor)
+		require.NoError(t, err)
+		query.Cursor = &cursor
+	}
+
+	// every archived item is reached by following the cursors
+	assert.Equal(t, items, got)
+}
+
+func TestService_GetFeedByCategory_HistoryCursorTies(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// several items are published at the same time
+	items := make([]news.Item, 30)
+	for i := range items {
+		items[i] = news.Item{
+			Provider: news.ProviderBBC,
+			Category: news.CategoryUK,
+			GUID:     fmt.Sprintf("%d", i),
+			DateTime: now.Add(-time.Duration(i/8) * time.Hour),
+		}
+	}
+
+	cache := cache_mock.NewMockCache(ctrl)
+	cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{}, true).AnyTimes()
+
+	service, err := service.New(cache,
+		service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+		service.WithCategory(news.CategoryUK),
+		service.WithArchive(newArchive(t, items)),
+	)
+	require.NoError(t, err)
+
+	var (
+		seen  = make(map[string]struct{})
+		query = news.Query{Limit: 3, History: true}
+	)
+	for {
+		res, err := service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, query)
+		require.NoError(t, err)
+
+		for _, i := range res.Items {
+			_, ok := seen[i.ID()]
+			require.False(t, ok, "item %s served twice", i.GUID)
+			seen[i.ID()] = struct{}{}
+		}
+
+		if res.NextCursor == "" {
+			break
+		}
+
+		cursor, err := news.ParseCursor(res.NextCursor)
+		require.NoError(t, err)
+		query.Cursor = &cursor
+	}
+
+	assert.Len(t, seen, len(items))
+}
+
+func TestService_GetFeedByCategory_HistoryOffset(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	items := make([]news.Item, 100)
+	for i := range items {
+		items[i] = news.Item{
+			Provider: news.ProviderBBC,
+			Category: news.CategoryUK,
+			GUID:     fmt.Sprintf("%d", i),
+			DateTime: now.Add(-time.Duration(i) * time.Hour),
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		offset          int
+		expectedItems   []news.Item
+		expectedHasMore bool
+	}{
+		{
+			name:            "first page",
+			expectedItems:   items[:20],
+			expectedHasMore: true,
+		},
+		{
+			name:            "page past the live feed",
+			offset:          40,
+			expectedItems:   items[40:60],
+			expectedHasMore: true,
+		},
+		{
+			name:          "last page",
+			offset:        80,
+			expectedItems: items[80:],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			// the live feed has the newest of the archived items
+			cache := cache_mock.NewMockCache(ctrl)
+			cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: items[:10]}, true)
+
+			service, err := service.New(cache,
+				service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+				service.WithCategory(news.CategoryUK),
+				service.WithArchive(newArchive(t, items)),
+			)
+			require.NoError(t, err)
+
+			res, err := service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, news.Query{Offset: tc.offset, Limit: 20, History: true})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedItems, res.Items)
+			assert.Nil(t, res.Total)
+			assert.Equal(t, tc.expectedHasMore, res.HasMore)
+		})
+	}
+}
+
+func TestService_GetFeedByCategory_Cluster(t *testing.T) {
+	now := time.Now()
+
+	var (
+		bbc   = news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "1", Title: "Storm Arwen brings floods to northern England", Link: "http://bbc.com/1", DateTime: now}
+		sky   = news.Item{Provider: news.ProviderSky, Category: news.CategoryUK, GUID: "2", Title: "Northern England floods as Storm Arwen hits", Link: "http://sky.com/2", DateTime: now.Add(-time.Minute)}
+		other = news.Item{Provider: news.ProviderSky, Category: news.CategoryUK, GUID: "3", Title: "Train fares rise by record amount", Link: "http://sky.com/3", DateTime: now.Add(-time.Hour)}
+	)
+
+	clusterID := bbc.ID()
+	if sky.ID() < clusterID {
+		clusterID = sky.ID()
+	}
+
+	annotated := func(item news.Item) news.Item {
+		item.ClusterID = clusterID
+		return item
+	}
+
+	collapsed := annotated(bbc)
+	collapsed.Alternates = []news.Alternate{{
+		Provider: sky.Provider,
+		Category: sky.Category,
+		Title:    sky.Title,
+		Link:     sky.Link,
+		DateTime: sky.DateTime,
+	}}
+
+	testCases := []struct {
+		name          string
+		query         news.Query
+		expectedItems []news.Item
+	}{
+		{
+			name:          "not clustered",
+			query:         news.Query{},
+			expectedItems: []news.Item{bbc, sky, other},
+		},
+		{
+			name:          "annotate",
+			query:         news.Query{Cluster: news.ClusterModeAnnotate},
+			expectedItems: []news.Item{annotated(bbc), annotated(sky), other},
+		},
+		{
+			name:          "collapse",
+			query:         news.Query{Cluster: news.ClusterModeCollapse},
+			expectedItems: []news.Item{collapsed, other},
+		},
+		{
+			name:          "collapse before paginating",
+			query:         news.Query{Cluster: news.ClusterModeCollapse, Offset: 1},
+			expectedItems: []news.Item{other},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			cache := cache_mock.NewMockCache(ctrl)
+			cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: []news.Item{bbc}}, true)
+			cache.EXPECT().Get(news.ProviderSky, news.CategoryUK).Return(&news.Feed{Items: []news.Item{sky, other}}, true)
+
+			service, err := service.New(cache,
+				service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+				service.WithProvider(news.ProviderSky, provider_mock.NewMockProvider(ctrl)),
+				service.WithCategory(news.CategoryUK),
+			)
+			require.NoError(t, err)
+
+			res, err := service.GetFeedByCategory(ctx, news.ProviderAll, news.CategoryUK, tc.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedItems, res.Items)
+		})
+	}
+}
+
+func TestService_GetFeedByCategory_Cursor(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+
+	item := func(guid string, age time.Duration) news.Item {
+		return news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: guid, DateTime: now.Add(-age)}
+	}
+
+	var (
+		a = item("a", 0)
+		b = item("b", time.Minute)
+		c = item("c", 2*time.Minute)
+		d = item("d", 2*time.Minute)
+		e = item("e", 3*time.Minute)
+	)
+
+	// items published at the same time are ordered by ID
+	if d.ID() < c.ID() {
+		c, d = d, c
+	}
+
+	cache := cache_mock.NewMockCache(ctrl)
+	gomock.InOrder(
+		cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: []news.Item{e, d, c, b, a}}, true),
+		// the feed is refreshed between pages, adding a new item and dropping one
+		cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: []news.Item{item("new", -time.Minute), a, c, d, e}}, true),
+		cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: []news.Item{a, c, d, e}}, true),
+	)
+
+	service, err := service.New(cache,
+		service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+		service.WithCategory(news.CategoryUK),
+	)
+	require.NoError(t, err)
+
+	res, err := service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, news.Query{Limit: 3})
+	require.NoError(t, err)
+	require.Equal(t, []news.Item{a, b, c}, res.Items)
+	require.NotEmpty(t, res.NextCursor)
+	assert.Equal(t, total(5), res.Total)
+	assert.True(t, res.HasMore)
+
+	cursor, err := news.ParseCursor(res.NextCursor)
+	require.NoError(t, err)
+
+	res, err = service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, news.Query{Cursor: &cursor, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, []news.Item{d}, res.Items)
+	require.NotEmpty(t, res.NextCursor)
+
+	cursor, err = news.ParseCursor(res.NextCursor)
+	require.NoError(t, err)
+
+	res, err = service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, news.Query{Cursor: &cursor, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []news.Item{e}, res.Items)
+	assert.Empty(t, res.NextCursor)
+	assert.False(t, res.HasMore)
+}
+
+func TestService_GetFeedByCategory_DateRange(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2021, 2, 6, 20, 0, 0, 0, time.UTC))
+	now := clock.Now()
+
+	item := func(guid string, age time.Duration) news.Item {
+		return news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: guid, DateTime: now.Add(-age)}
+	}
+
+	var (
+		a = item("a", time.Hour)
+		b = item("b", 2*time.Hour)
+		c = item("c", 3*time.Hour)
+		d = item("d", 4*time.Hour)
+	)
+
+	testCases := []struct {
+		name          string
+		query         news.Query
+		expectedItems []news.Item
+		expectedTotal int
+	}{
+		{
+			name:          "no range",
+			query:         news.Query{},
+			expectedItems: []news.Item{a, b, c, d},
+			expectedTotal: 4,
+		},
+		{
+			name:          "since",
+			query:         news.Query{Since: c.DateTime},
+			expectedItems: []news.Item{a, b, c},
+			expectedTotal: 3,
+		},
+		{
+			name:          "until",
+			query:         news.Query{Until: b.DateTime},
+			expectedItems: []news.Item{b, c, d},
+			expectedTotal: 3,
+		},
+		{
+			name:          "max age",
+			query:         news.Query{MaxAge: 150 * time.Minute},
+			expectedItems: []news.Item{a, b},
+			expectedTotal: 2,
+		},
+		{
+			name:          "later of since and max age",
+			query:         news.Query{Since: b.DateTime.Add(time.Minute), MaxAge: 150 * time.Minute},
+			expectedItems: []news.Item{a},
+			expectedTotal: 1,
+		},
+		{
+			name:          "filtered before pagination",
+			query:         news.Query{Until: a.DateTime.Add(-time.Minute), Limit: 2},
+			expectedItems: []news.Item{b, c},
+			expectedTotal: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			cache := cache_mock.NewMockCache(ctrl)
+			cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: []news.Item{d, c, b, a}}, true)
+
+			service, err := service.New(cache,
+				service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+				service.WithCategory(news.CategoryUK),
+				service.WithClock(clock),
+			)
+			require.NoError(t, err)
+
+			res, err := service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, tc.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedItems, res.Items)
+			assert.Equal(t, total(tc.expectedTotal), res.Total)
+		})
+	}
+}
+
+func TestService_GetFeedByCategory_HistoryDateRange(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2021, 2, 6, 20, 0, 0, 0, time.UTC))
+	now := clock.Now()
+
+	items := make([]news.Item, 100)
+	for i := range items {
+		items[i] = news.Item{
+			Provider: news.ProviderBBC,
+			Category: news.CategoryUK,
+			GUID:     fmt.Sprintf("%d", i),
+			DateTime: now.Add(-time.Duration(i) * time.Hour),
+		}
+	}
+
+	testCases := []struct {
+		name            string
+		query           news.Query
+		expectedItems   []news.Item
+		expectedHasMore bool
+	}{
+		{
+			name:            "until past the live feed",
+			query:           news.Query{Until: now.Add(-50 * time.Hour), Limit: 20, History: true},
+			expectedItems:   items[50:70],
+			expectedHasMore: true,
+		},
+		{
+			name:          "since and until",
+			query:         news.Query{Since: now.Add(-90 * time.Hour), Until: now.Add(-80 * time.Hour), Limit: 20, History: true},
+			expectedItems: items[80:91],
+		},
+		{
+			name:          "max age",
+			query:         news.Query{MaxAge: 5 * time.Hour, Limit: 20, History: true},
+			expectedItems: items[:6],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			cache := cache_mock.NewMockCache(ctrl)
+			cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&news.Feed{Items: items[:10]}, true)
+
+			service, err := service.New(cache,
+				service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+				service.WithCategory(news.CategoryUK),
+				service.WithArchive(newArchive(t, items)),
+				service.WithClock(clock),
+			)
+			require.NoError(t, err)
+
+			res, err := service.GetFeedByCategory(ctx, news.ProviderBBC, news.CategoryUK, tc.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedItems, res.Items)
+			assert.Equal(t, tc.expectedHasMore, res.HasMore)
+		})
+	}
+}
+
+func TestService_GetFeed_IndexChangedFeeds(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	clock := clockwork.NewFakeClock()
+
+	var (
+		// the cached feed was fetched by another instance sharing the cache
+		cached    = news.Feed{Items: []news.Item{{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "1"}}, FetchedAt: clock.Now().Add(-time.Minute)}
+		refreshed = news.Feed{Items: []news.Item{{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "3"}}, FetchedAt: clock.Now()}
+		fetched   = news.Feed{Items: []news.Item{{Provider: news.ProviderSky, Category: news.CategoryUK, GUID: "2"}}}
+		stored    = fetchedAt(fetched, clock.Now())
+		index     = &countingIndex{}
+	)
+
+	cache := cache_mock.NewMockCache(ctrl)
+	cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&cached, true).Times(2)
+	cache.EXPECT().Get(news.ProviderBBC, news.CategoryUK).Return(&refreshed, true)
+	cache.EXPECT().Get(news.ProviderSky, news.CategoryUK).Return(nil, false)
+	cache.EXPECT().GetStale(news.ProviderSky, news.CategoryUK).Return(nil, false)
+	cache.EXPECT().Store(news.ProviderSky, news.CategoryUK, stored)
+	cache.EXPECT().Get(news.ProviderSky, news.CategoryUK).Return(&stored, true).Times(2)
+
+	sky := provider_mock.NewMockProvider(ctrl)
+	sky.EXPECT().GetFeed(gomock.Any(), news.CategoryUK).Return(&fetched, nil)
+
+	service, err := service.New(cache,
+		service.WithProvider(news.ProviderBBC, provider_mock.NewMockProvider(ctrl)),
+		service.WithProvider(news.ProviderSky, sky),
+		service.WithCategory(news.CategoryUK),
+		service.WithIndex(index),
+		service.WithClock(clock),
+	)
+	require.NoError(t, err)
+
+	// the cached feed is indexed when it's first read, and the fetched feed when it's fetched
+	_, err = service.GetFeed(ctx, news.ProviderAll, news.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, index.items)
+
+	// neither feed has changed
+	_, err = service.GetFeed(ctx, news.ProviderAll, news.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, index.items)
+
+	// the cached feed has been fetched again by another instance
+	_, err = service.GetFeed(ctx, news.ProviderAll, news.Query{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, index.items)
+}
+
+func TestService_Search(t *testing.T) {
+	now := time.Now()
+
+	var (
+		floods = news.Item{Provider: news.ProviderBBC, Category: news.CategoryUK, GUID: "1", Title: "Floods hit northern England", DateTime: now}
+		rain   = news.Item{Provider: news.ProviderSky, Category: news.CategoryUK, GUID: "2", Title: "More rain and flooding on the way", DateTime: now.Add(-time.Hour)}
+		chips  = news.Item{Provider: news.ProviderBBC, Category: news.CategoryTechnology, GUID: "3", Title: "Floods halt chip production", DateTime: now.Add(-2 * time.Hour)}
+	)
+
+	testCases := []struct {
+		name           string
+		provider       news.Provider
+		category       news.Category
+		query          news.SearchQuery
+		expectedTitles []string
+		expectedErr    error
+	}{
+		{
+			name:           "every provider and category",
+			provider:       news.ProviderAll,
+			query:          news.SearchQuery{Text: "flood"},
+			expectedTitles: []string{floods.Title, rain.Title, chips.Title},
+		},
+		{
+			name:           "provider",
+			provider:       news.ProviderSky,
+			query:          news.SearchQuery{Text: "flood"},
+			expectedTitles: []string{rain.Title},
+		},
+		{
+			name:           "category",
+			provider:       news.ProviderAll,
+			category:       news.CategoryTechnology,
+			query:          news.SearchQuery{Text: "flood"},
+			expectedTitles: []string{chips.Title},
+		},
+		{
+			name:           "excluded provider",
+			provider:       news.ProviderAll,
+			query:          news.SearchQuery{Text: "flood", Query: news.Query{ExcludeProviders: []news.Provider{news.ProviderBBC}}},
+			expectedTitles: []string{rain.Title},
+		},
+		{
+			name:           "excluded category",
+			provider:       news.ProviderAll,
+			query:          news.SearchQuery{Text: "flood", Query: news.Query{ExcludeCategories: []news.Category{news.CategoryUK}}},
+			expectedTitles: []string{chips.Title},
+		},
+		{
+			name:     "everything excluded",
+			provider: news.ProviderBBC,
+			query:    news.SearchQuery{Text: "flood", Query: news.Query{ExcludeProviders: []news.Provider{news.ProviderBBC}}},
+		},
+		{
+			name:           "since",
+			provider:       news.ProviderAll,
+			query:          news.SearchQuery{Text: "flood", Query: news.Query{Since: now.Add(-90 * time.Minute)}},
+			expectedTitles: []string{floods.Title, rain.Title},
+		},
+		{
+			name:           "paginated",
+			provider:       news.ProviderAll,
+			query:          news.SearchQuery{Text: "flood", Query: news.Query{Offset: 1, Limit: 1}},
+			expectedTitles: []string{rain.Title},
+		},
+		{
+			name:        "category not found",
+			provider:    news.ProviderAll,
+			category:    "invalid category",
+			query:       news.SearchQuery{Text: "flood"},
+			expectedErr: news.ErrCategoryNotFound,
+		},
+		{
+			name:        "provider not found",
+			provider:    "invalid provider",
+			query:       news.SearchQuery{Text: "flood"},
+			expectedErr: news.ErrProviderNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			feeds := map[news.Provider]map[news.Category]*news.Feed{
+				news.ProviderBBC: {
+					news.CategoryUK:         {Items: []news.Item{floods}},
+					news.CategoryTechnology: {Items: []news.Item{chips}},
+				},
+				news.ProviderSky: {
+					news.CategoryUK:         {Items: []news.Item{rain}},
+					news.CategoryTechnology: {},
+				},
+			}
+
+			// feeds are indexed when they're fetched, so the cache misses
+			cache := cache_mock.NewMockCache(ctrl)
+			cache.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, false).AnyTimes()
+			cache.EXPECT().GetStale(gomock.Any(), gomock.Any()).Return(nil, false).AnyTimes()
+			cache.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			provider := func(name news.Provider) *provider_mock.MockProvider {
+				p := provider_mock.NewMockProvider(ctrl)
+				p.EXPECT().GetFeed(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c news.Category) (*news.Feed, error) {
+					return feeds[name][c], nil
+				}).AnyTimes()
+				return p
+			}
+
+			service, err := service.New(cache,
+				service.WithProvider(news.ProviderBBC, provider(news.ProviderBBC)),
+				service.WithProvider(news.ProviderSky, provider(news.ProviderSky)),
+				service.WithCategory(news.CategoryUK),
+				service.WithCategory(news.CategoryTechnology),
+			)
+			require.NoError(t, err)
+
+			res, err := service.Search(ctx, tc.provider, tc.category, tc.query)
+			if tc.expectedErr != nil {
+				require.True(t, errors.Is(err, tc.expectedErr))
+				return
+			}
+			require.NoError(t, err)
+
+			var titles []string
+			for _, i := range res.Items {
+				titles = append(titles, i.Title)
+				assert.NotNil(t, i.Highlight)
+			}
+			assert.Equal(t, tc.expectedTitles, titles)
+		})
+	}
+}
+
+func TestService_GetFeedByCategory_Error(t *testing.T) {
+	const testErr = testError("error")
+	testCases := []struct {
+		name               string
+		provider           news.Provider
+		providerCategories []news.Category
+		category           news.Category
+		mockTimes          int
+		getFeedErr         error
+		expectedErr        error
+	}{
+		{
+			name:        "invalid provider",
+			provider:    "invalid provider",
+			category:    news.CategoryUK,
+			mockTimes:   0,
+			expectedErr: news.ErrProviderNotFound,
+		},
+		{
+			name:        "invalid category",
+			provider:    news.ProviderAll,
+			category:    "invalid category",
+			mockTimes:   0,
+			expectedErr: news.ErrCategoryNotFound,
+		},
+		{
+			name:               "provider does not support category",
+			provider:           news.ProviderBBC,
+			providerCategories: []news.Category{news.CategoryTechnology},
+			category:           news.CategoryUK,
+			mockTimes:          0,
+			expectedErr:        news.ErrCategoryNotFound,
+		},
+		{
+			name:        "error getting feed",
+			provider:    news.ProviderAll,
+			category:    news.CategoryUK,
+			mockTimes:   1,
+			getFeedErr:  testErr,
+			expectedErr: testErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			cache := cache_mock.NewMockCache(ctrl)
+			provider := provider_mock.NewMockProvider(ctrl)
+
+			cache.EXPECT().
+				Get(tc.provider, news.CategoryUK).
+				Return(nil, false).
+				Times(tc.mockTimes)
+
+			cache.EXPECT().
+				GetStale(tc.provider, news.CategoryUK).
+				Return(nil, false).
+				Times(tc.mockTimes)
+
+			provider.EXPECT().
+				GetFeed(gomock.Any(), news.CategoryUK).
+				Return(nil, tc.getFeedErr).
+				Times(tc.mockTimes)
+
+			opts := []service.Option{
+				service.WithProvider(news.ProviderAll, provider),
+				service.WithCategory(news.CategoryUK),
+				service.WithCategory(news.CategoryTechnology),
+			}
+			if len(tc.providerCategories) > 0 {
+				opts = append(opts, service.WithProvider(tc.provider, provider, tc.providerCategories...))
+			}
+
+			service, err := service.New(cache, opts...)
+			require.NoError(t, err)
+
+			res, err := service.GetFeedByCategory(ctx, tc.provider, tc.category, news.Query{})
+			require.Error(t, err)
+			require.Nil(t, res)
+
+			assert.True(t, errors.Is(err, tc.expectedErr))
+		})
+	}
+}
+
+func TestService_GetFeedByCategory_Success(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	var (
+		item1 = news.Item{Provider: news.ProviderBBC, GUID: "1", DateTime: time.Now().Add(time.Second)}
+		item2 = news.Item{Provider: news.ProviderSky, GUID: "2", DateTime: time.Now()}
+	)
+
+	type provider struct {
+		name       news.Provider
+		provider   *provider_mock.MockProvider
+		items      []news.Item
+		cached     bool
+		categories []news.Category
+	}
+	testCases := []struct {
+		name           string
+		providers      []provider
+		category       news.Category
+		provider       news.Provider
+		limit          int
+		offset         int
+		cacheFeed      bool
+		expectedResult *news.FeedResponse
+	}{
+		{
+			name: "return cached data",
+			providers: []provider{
+				{
+					name:     news.ProviderBBC,
+					provider: provider_mock.NewMockProvider(ctrl),
+					cached:   true,
+					items:    []news.Item{item1},
+				},
+				{
+					name:     news.ProviderSky,
+					provider: provider_mock.NewMockProvider(ctrl),
+					cached:   true,
+					items:    []news.Item{item2},
+				},
+			},
+			category:  news.CategoryUK,
+			provider:  news.ProviderAll,
+			cacheFeed: false,
+			expectedResult: &news.FeedResponse{
+				Category: news.CategoryUK,
+				Provider: news.ProviderAll,
+				Items:    []news.Item{item1, item2},
+				Total:    total(2),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+				},
+			},
+		},
+		{
+			name: "retrieve data",
+			providers: []provider{
+				{
+					name:     news.ProviderBBC,
+					provider: provider_mock.NewMockProvider(ctrl),
+					cached:   false,
+					items:    []news.Item{item1},
+				},
+				{
+					name:     news.ProviderSky,
+					provider: provider_mock.NewMockProvider(ctrl),
+					cached:   false,
+					items:    []news.Item{item2},
+				},
+			},
+			category:  news.CategoryUK,
+			provider:  news.ProviderAll,
+			cacheFeed: false,
+			expectedResult: &news.FeedResponse{
+				Category: news.CategoryUK,
+				Provider: news.ProviderAll,
+				Items:    []news.Item{item1, item2},
+				Total:    total(2),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusOK},
+				},
+			},
+		},
+		{
+			name: "skip providers not serving category",
+			providers: []provider{
+				{
+					name:     news.ProviderBBC,
+					provider: provider_mock.NewMockProvider(ctrl),
+					cached:   true,
+					items:    []news.Item{item1},
+				},
+				{
+					name:       news.ProviderSky,
+					provider:   provider_mock.NewMockProvider(ctrl),
+					items:      []news.Item{item2},
+					categories: []news.Category{news.CategoryTechnology},
+				},
+			},
+			category:  news.CategoryUK,
+			provider:  news.ProviderAll,
+			cacheFeed: false,
+			expectedResult: &news.FeedResponse{
+				Category: news.CategoryUK,
+				Provider: news.ProviderAll,
+				Items:    []news.Item{item1},
+				Total:    total(1),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+				},
+			},
+		},
+		{
+			name:      "no providers enabled",
+			providers: []provider{},
+			category:  news.CategoryUK,
+			provider:  news.ProviderAll,
+			cacheFeed: false,
+			expectedResult: &news.FeedResponse{
+				Category: news.CategoryUK,
+				Provider: news.ProviderAll,
+				Sources:  []news.Source{},
+				Total:    total(0),
+			},
+		},
+		{
+			name: "limit results",
+			providers: []provider{
+				{
+					name:     news.ProviderBBC,
+					provider: provider_mock.NewMockProvider(ctrl),
+					cached:   true,
+					items:    []news.Item{item1},
+				},
+				{
+					name:     news.ProviderSky,
+					provider: provider_mock.NewMockProvider(ctrl),
+					cached:   true,
+					items:    []news.Item{item2},
+				},
+			},
+			category:  news.CategoryUK,
+			provider:  news.ProviderAll,
+			cacheFeed: false,
+			limit:     1,
+			expectedResult: &news.FeedResponse{
+				Category:   news.CategoryUK,
+				Provider:   news.ProviderAll,
+				Items:      []news.Item{item1},
+				Limit:      1,
+				Total:      total(2),
+				HasMore:    true,
+				NextCursor: news.NewCursor(item1).String(),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+				},
+			},
+		},
+		{
+			name: "offset results",
+			providers: []provider{
+				{
+					name:     news.ProviderBBC,
+					provider: provider_mock.NewMockProvider(ctrl),
+					cached:   true,
+					items:    []news.Item{item1},
+				},
+				{
+					name:     news.ProviderSky,
+					provider: provider_mock.NewMockProvider(ctrl),
+					cached:   true,
+					items:    []news.Item{item2},
+				},
+			},
+			category:  news.CategoryUK,
+			provider:  news.ProviderAll,
+			cacheFeed: false,
+			offset:    1,
+			expectedResult: &news.FeedResponse{
+				Category: news.CategoryUK,
+				Provider: news.ProviderAll,
+				Items:    []news.Item{item2},
+				Offset:   1,
+				Total:    total(2),
+				Sources: []news.Source{
+					{Provider: news.ProviderBBC, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+					{Provider: news.ProviderSky, Category: news.CategoryUK, Status: news.SourceStatusOK, Cached: true},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := cache_mock.NewMockCache(ctrl)
+			clock := clockwork.NewFakeClock()
+
+			opts := []service.Option{
+				service.WithCategory(tc.category),
+				service.WithClock(clock),
+			}
+
+			for _, p := range tc.providers {
+				opts = append(opts, service.WithProvider(p.name, p.provider, p.categories...))
+
+				if !serves(p.categories, tc.category) {
+					continue
+				}
+
+				feed := &news.Feed{Items: p.items}
+
+				cache.EXPECT().Get(p.name, tc.category).Return(feed, p.cached)
+
+				if !p.cached {
+					cache.EXPECT().GetStale(p.name, tc.category).Return(nil, false)
+					p.provider.EXPECT().GetFeed(gomock.Any(), tc.category).Return(feed, nil)
+					cache.EXPECT().Store(p.name, tc.category, fetchedAt(*feed, clock.Now()))
+				}
+			}
+
+			service, err := service.New(cache, opts...)
+			require.NoError(t, err)
+
+			res, err := service.GetFeedByCategory(ctx, tc.provider, tc.category, news.Query{Offset: tc.offset, Limit: tc.limit})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func serves(categories []news.Category, category news.Category) bool {
+	if len(categories) == 0 {
+		return true
+	}
+
+	for _, c := range categories {
+		if c == category {
+			return true
+		}
+	}
+
+	return false
 }
@@ -1,3 +0,0 @@
-package bbc
-
-type ResTime = resTime
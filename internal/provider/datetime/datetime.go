@@ -0,0 +1,108 @@
+package datetime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrMissing is returned when there's no date to parse.
+var ErrMissing = errors.New("missing date")
+
+// layouts are the date formats found in real-world feeds, most common first.
+var layouts = []string{
+	// RFC 822/1123 with 1 or 2 digit days, numeric or named zones and optional seconds
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	// without weekday
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 MST",
+	// two digit years
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05 MST",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04:05 MST",
+	// ISO 8601
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// zones are the named zones defined by RFC 822, along with UTC, which the time
+// package can only resolve when they match the local zone. Other abbreviations are ambiguous,
+// so dates using them are rejected rather than assumed to be UTC.
+var zones = map[string]string{
+	"UT":  "+0000",
+	"UTC": "+0000",
+	"GMT": "+0000",
+	"Z":   "+0000",
+	"EST": "-0500",
+	"EDT": "-0400",
+	"CST": "-0600",
+	"CDT": "-0500",
+	"MST": "-0700",
+	"MDT": "-0600",
+	"PST": "-0800",
+	"PDT": "-0700",
+}
+
+// Parse parses a feed date in any of the RFC 822, RFC 1123 and ISO 8601 variants
+// used by feeds, returning it in UTC. Dates without a zone are assumed to be UTC,
+// while dates with an unknown named zone fail. An empty string fails with ErrMissing.
+func Parse(s string) (time.Time, error) {
+	s, err := normalise(s)
+	switch {
+	case err != nil:
+		return time.Time{}, err
+	case s == "":
+		return time.Time{}, ErrMissing
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised date format: %q", s)
+}
+
+// normalise collapses whitespace and replaces RFC 822 named zones with offsets,
+// failing if the zone isn't one of them.
+func normalise(s string) (string, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return strings.Join(fields, " "), nil
+	}
+
+	zone := fields[len(fields)-1]
+	if !isAlpha(zone) {
+		return strings.Join(fields, " "), nil
+	}
+
+	offset, ok := zones[zone]
+	if !ok {
+		return "", fmt.Errorf("unknown time zone: %q", zone)
+	}
+	fields[len(fields)-1] = offset
+
+	return strings.Join(fields, " "), nil
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package datetime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/provider/datetime"
+)
+
+func TestParse_Error(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expectedError string
+	}{
+		{name: "not a date", value: "yesterday", expectedError: "unrecognised date format"},
+		{name: "invalid day", value: "Mon, 32 Feb 2021 20:47:21 GMT", expectedError: "unrecognised date format"},
+		{name: "missing time", value: "Sat, 06 Feb 2021", expectedError: "unrecognised date format"},
+		{name: "unknown zone", value: "Sat, 06 Feb 2021 21:47:21 BST", expectedError: "unknown time zone"},
+		{name: "unknown zone without weekday", value: "06 Feb 2021 21:47 CET", expectedError: "unknown time zone"},
+		{name: "empty", value: "", expectedError: "missing date"},
+		{name: "whitespace", value: " \n ", expectedError: "missing date"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := datetime.Parse(tc.value)
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), tc.expectedError)
+		})
+	}
+}
+
+func TestParse_Success(t *testing.T) {
+	expected := time.Date(2021, time.February, 6, 20, 47, 21, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		value    string
+		expected time.Time
+	}{
+		{name: "rfc1123", value: "Sat, 06 Feb 2021 20:47:21 GMT", expected: expected},
+		{name: "rfc1123z", value: "Sat, 06 Feb 2021 21:47:21 +0100", expected: expected},
+		{name: "single digit day", value: "Sat, 6 Feb 2021 20:47:21 GMT", expected: expected},
+		{name: "missing weekday", value: "06 Feb 2021 20:47:21 +0000", expected: expected},
+		{name: "two digit year", value: "Sat, 06 Feb 21 20:47:21 GMT", expected: expected},
+		{name: "missing seconds", value: "Sat, 06 Feb 2021 20:47 GMT", expected: expected.Truncate(time.Minute)},
+		{name: "ut zone", value: "Sat, 06 Feb 2021 20:47:21 UT", expected: expected},
+		{name: "us zone", value: "Sat, 06 Feb 2021 15:47:21 EST", expected: expected},
+		{name: "extra whitespace", value: "\n  Sat,  06 Feb 2021 20:47:21 GMT\n", expected: expected},
+		{name: "rfc3339", value: "2021-02-06T20:47:21Z", expected: expected},
+		{name: "rfc3339 with offset", value: "2021-02-06T22:47:21+02:00", expected: expected},
+		{name: "rfc3339 with fraction", value: "2021-02-06T20:47:21.000Z", expected: expected},
+		{name: "iso8601 without colon", value: "2021-02-06T20:47:21+0000", expected: expected},
+		{name: "iso8601 without zone", value: "2021-02-06T20:47:21", expected: expected},
+		{name: "iso8601 with space", value: "2021-02-06 20:47:21", expected: expected},
+		{name: "date only", value: "2021-02-06", expected: expected.Truncate(24 * time.Hour)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := datetime.Parse(tc.value)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
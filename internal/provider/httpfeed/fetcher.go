@@ -0,0 +1,214 @@
+package httpfeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/cshep4/news-api/internal/news"
+)
+
+const (
+	// DefaultMaxBodySize is the default limit on the size of a feed document in bytes.
+	DefaultMaxBodySize = 10 << 20
+	// DefaultMaxItems is the default limit on the number of items returned for a feed.
+	DefaultMaxItems = 100
+
+	placeholderBase     = "{base}"
+	placeholderCategory = "{category}"
+)
+
+// ErrBodyTooLarge is returned when a feed document exceeds the max body size.
+var ErrBodyTooLarge = errors.New("body too large")
+
+type (
+	// Decoder maps a feed document for a category to a feed. Only the first maxItems
+	// items of the document are decoded, so that a large document isn't held in memory.
+	Decoder func(ctx context.Context, r io.Reader, category news.Category, maxItems int) (*news.Feed, error)
+
+	fetcher struct {
+		url         string
+		client      *http.Client
+		urlTemplate string
+		maxBodySize int64
+		maxItems    int
+
+		mutex      sync.Mutex
+		validators map[news.Category]validator
+	}
+
+	// StatusError is returned when the upstream responds with an unexpected status code.
+	StatusError struct {
+		StatusCode int
+	}
+
+	// validator holds the cache validators returned with a feed document, along
+	// with the feed decoded from it so that it can be reused on a 304 response.
+	validator struct {
+		etag         string
+		lastModified string
+		feed         news.Feed
+	}
+
+	// limitedReader reads from r until n bytes remain, then fails with ErrBodyTooLarge.
+	limitedReader struct {
+		r        io.Reader
+		n        int64
+		exceeded bool
+	}
+)
+
+// New creates a fetcher which retrieves feed documents from url, using urlTemplate
+// to build the URL for each category, e.g. "{base}/{category}.xml".
+func New(url string, client *http.Client, urlTemplate string, opts ...Option) (*fetcher, error) {
+	f := &fetcher{
+		url:         url,
+		client:      client,
+		urlTemplate: urlTemplate,
+		maxBodySize: DefaultMaxBodySize,
+		maxItems:    DefaultMaxItems,
+		validators:  make(map[news.Category]validator),
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	switch {
+	case url == "":
+		return nil, news.InvalidParameterError{Parameter: "url"}
+	case client == nil:
+		return nil, news.InvalidParameterError{Parameter: "client"}
+	case !strings.Contains(urlTemplate, placeholderCategory):
+		return nil, news.InvalidParameterError{Parameter: "urlTemplate"}
+	case f.maxBodySize <= 0:
+		return nil, news.InvalidParameterError{Parameter: "maxBodySize"}
+	case f.maxItems <= 0:
+		return nil, news.InvalidParameterError{Parameter: "maxItems"}
+	}
+
+	return f, nil
+}
+
+// Fetch retrieves the feed document for category and decodes it with decode.
+// Requests are made conditional on the validators of the last document, and the
+// previously decoded feed is returned if the document has not been modified.
+// The document is streamed to decode, failing once it exceeds the max body size,
+// and only the max number of items are decoded from it.
+func (f *fetcher) Fetch(ctx context.Context, category news.Category, decode Decoder) (*news.Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.buildUrl(category), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	v, cached := f.validator(category)
+	if cached {
+		if v.etag != "" {
+			req.Header.Set("If-None-Match", v.etag)
+		}
+		if v.lastModified != "" {
+			req.Header.Set("If-Modified-Since", v.lastModified)
+		}
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached:
+		return copyFeed(v.feed), nil
+	case res.StatusCode != http.StatusOK:
+		return nil, StatusError{StatusCode: res.StatusCode}
+	}
+
+	body := &limitedReader{r: res.Body, n: f.maxBodySize}
+
+	feed, err := decode(ctx, body, category, f.maxItems)
+	switch {
+	case body.exceeded:
+		return nil, fmt.Errorf("failed to read body: %w", ErrBodyTooLarge)
+	case err != nil:
+		return nil, err
+	}
+
+	if len(feed.Items) > f.maxItems {
+		feed.Items = feed.Items[:f.maxItems]
+	}
+
+	f.storeValidator(category, validator{
+		etag:         res.Header.Get("ETag"),
+		lastModified: res.Header.Get("Last-Modified"),
+		feed:         *copyFeed(*feed),
+	})
+
+	return feed, nil
+}
+
+func (f *fetcher) validator(category news.Category) (validator, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	v, ok := f.validators[category]
+	return v, ok
+}
+
+func (f *fetcher) storeValidator(category news.Category, v validator) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if v.etag == "" && v.lastModified == "" {
+		delete(f.validators, category)
+		return
+	}
+
+	f.validators[category] = v
+}
+
+func (f *fetcher) buildUrl(category news.Category) string {
+	return strings.NewReplacer(
+		placeholderBase, f.url,
+		placeholderCategory, string(category),
+	).Replace(f.urlTemplate)
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Temporary reports whether the request may succeed if it is retried.
+func (e StatusError) Temporary() bool {
+	return e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// a document of exactly the max size is allowed, so only fail if there is more to read
+		n, err := l.r.Read(make([]byte, 1))
+		if n > 0 {
+			l.exceeded = true
+			return 0, ErrBodyTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// copyFeed copies feed so that callers can't modify the items of a stored feed.
+func copyFeed(feed news.Feed) *news.Feed {
+	feed.Items = append([]news.Item(nil), feed.Items...)
+	return &feed
+}
@@ -0,0 +1,335 @@
+package httpfeed_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/provider/httpfeed"
+)
+
+const urlTemplate = "{base}/{category}.xml"
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+type errorRoundTripper struct{ err error }
+
+func (e errorRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, e.err
+}
+
+func TestNew_Error(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		url                    string
+		client                 *http.Client
+		urlTemplate            string
+		opts                   []httpfeed.Option
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "url is empty",
+			url:                    "",
+			urlTemplate:            urlTemplate,
+			expectedErrorParameter: "url",
+		},
+		{
+			name:                   "client is invalid",
+			url:                    "https://test.com",
+			client:                 nil,
+			urlTemplate:            urlTemplate,
+			expectedErrorParameter: "client",
+		},
+		{
+			name:                   "url template has no category",
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			urlTemplate:            "{base}/rss.xml",
+			expectedErrorParameter: "urlTemplate",
+		},
+		{
+			name:                   "max body size is invalid",
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			urlTemplate:            urlTemplate,
+			opts:                   []httpfeed.Option{httpfeed.WithMaxBodySize(0)},
+			expectedErrorParameter: "maxBodySize",
+		},
+		{
+			name:                   "max items is invalid",
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			urlTemplate:            urlTemplate,
+			opts:                   []httpfeed.Option{httpfeed.WithMaxItems(-1)},
+			expectedErrorParameter: "maxItems",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fetcher, err := httpfeed.New(tc.url, tc.client, tc.urlTemplate, tc.opts...)
+			require.Error(t, err)
+			require.Nil(t, fetcher)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestFetcher_Fetch_Error(t *testing.T) {
+	testCases := []struct {
+		name       string
+		client     *http.Client
+		statusCode int
+		decodeErr  error
+		expectedEr string
+	}{
+		{
+			name: "request error",
+			client: &http.Client{
+				Transport: errorRoundTripper{err: testError("error")},
+			},
+			statusCode: http.StatusOK,
+			expectedEr: "failed to do request",
+		},
+		{
+			name:       "status code not 200",
+			statusCode: http.StatusTeapot,
+			expectedEr: "unexpected status code: 418",
+		},
+		{
+			name:       "decode error",
+			statusCode: http.StatusOK,
+			decodeErr:  testError("decode error"),
+			expectedEr: "decode error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer s.Close()
+
+			if tc.client == nil {
+				tc.client = s.Client()
+			}
+
+			fetcher, err := httpfeed.New(s.URL, tc.client, urlTemplate)
+			require.NoError(t, err)
+
+			_, err = fetcher.Fetch(context.Background(), "category", func(context.Context, io.Reader, news.Category, int) (*news.Feed, error) {
+				return nil, tc.decodeErr
+			})
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), tc.expectedEr)
+		})
+	}
+}
+
+func TestFetcher_Fetch_Success(t *testing.T) {
+	const body = "body"
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/news/category.xml", r.URL.Path)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer s.Close()
+
+	fetcher, err := httpfeed.New(s.URL+"/news", s.Client(), urlTemplate)
+	require.NoError(t, err)
+
+	feed, err := fetcher.Fetch(context.Background(), "category", func(_ context.Context, r io.Reader, category news.Category, _ int) (*news.Feed, error) {
+		b, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+
+		return &news.Feed{Title: string(b), Items: []news.Item{{Category: category}}}, nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &news.Feed{Title: body, Items: []news.Item{{Category: "category"}}}, feed)
+}
+
+func TestFetcher_Fetch_MaxBodySize(t *testing.T) {
+	const body = "0123456789"
+
+	testCases := []struct {
+		name        string
+		maxBodySize int64
+		expectedErr error
+	}{
+		{
+			name:        "body within limit",
+			maxBodySize: int64(len(body)),
+		},
+		{
+			name:        "body too large",
+			maxBodySize: int64(len(body)) - 1,
+			expectedErr: httpfeed.ErrBodyTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(body))
+			}))
+			defer s.Close()
+
+			fetcher, err := httpfeed.New(s.URL, s.Client(), urlTemplate, httpfeed.WithMaxBodySize(tc.maxBodySize))
+			require.NoError(t, err)
+
+			feed, err := fetcher.Fetch(context.Background(), "category", func(_ context.Context, r io.Reader, _ news.Category, _ int) (*news.Feed, error) {
+				b, err := ioutil.ReadAll(r)
+				if err != nil {
+					return nil, err
+				}
+				return &news.Feed{Title: string(b)}, nil
+			})
+			if tc.expectedErr != nil {
+				require.True(t, errors.Is(err, tc.expectedErr))
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, body, feed.Title)
+		})
+	}
+}
+
+func TestFetcher_Fetch_MaxItems(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("body"))
+	}))
+	defer s.Close()
+
+	fetcher, err := httpfeed.New(s.URL, s.Client(), urlTemplate, httpfeed.WithMaxItems(2))
+	require.NoError(t, err)
+
+	feed, err := fetcher.Fetch(context.Background(), "category", func(_ context.Context, _ io.Reader, _ news.Category, maxItems int) (*news.Feed, error) {
+		assert.Equal(t, 2, maxItems)
+		return &news.Feed{Items: []news.Item{{Title: "1"}, {Title: "2"}, {Title: "3"}}}, nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []news.Item{{Title: "1"}, {Title: "2"}}, feed.Items)
+}
+
+func TestFetcher_Fetch_NotModified(t *testing.T) {
+	const lastModified = "Sat, 06 Feb 2021 20:47:21 GMT"
+
+	testCases := []struct {
+		name            string
+		header          string
+		value           string
+		conditionHeader string
+	}{
+		{
+			name:            "etag",
+			header:          "ETag",
+			value:           `"v1"`,
+			conditionHeader: "If-None-Match",
+		},
+		{
+			name:            "last modified",
+			header:          "Last-Modified",
+			value:           lastModified,
+			conditionHeader: "If-Modified-Since",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests int
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.Header.Get(tc.conditionHeader) == tc.value {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set(tc.header, tc.value)
+				_, _ = w.Write([]byte("body"))
+			}))
+			defer s.Close()
+
+			fetcher, err := httpfeed.New(s.URL, s.Client(), urlTemplate)
+			require.NoError(t, err)
+
+			var decodes int
+			decode := func(context.Context, io.Reader, news.Category, int) (*news.Feed, error) {
+				decodes++
+				return &news.Feed{Title: "feed", Items: []news.Item{{Title: "item"}}}, nil
+			}
+
+			first, err := fetcher.Fetch(context.Background(), "category", decode)
+			require.NoError(t, err)
+
+			first.Items[0].Title = "modified"
+
+			second, err := fetcher.Fetch(context.Background(), "category", decode)
+			require.NoError(t, err)
+
+			assert.Equal(t, 2, requests)
+			assert.Equal(t, 1, decodes)
+			assert.Equal(t, &news.Feed{Title: "feed", Items: []news.Item{{Title: "item"}}}, second)
+		})
+	}
+}
+
+func TestFetcher_Fetch_NoValidators(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		assert.Empty(t, r.Header.Get("If-Modified-Since"))
+		_, _ = w.Write([]byte("body"))
+	}))
+	defer s.Close()
+
+	fetcher, err := httpfeed.New(s.URL, s.Client(), urlTemplate)
+	require.NoError(t, err)
+
+	var decodes int
+	decode := func(context.Context, io.Reader, news.Category, int) (*news.Feed, error) {
+		decodes++
+		return &news.Feed{}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := fetcher.Fetch(context.Background(), "category", decode)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, decodes)
+}
+
+func TestStatusError_Temporary(t *testing.T) {
+	testCases := []struct {
+		name       string
+		statusCode int
+		expected   bool
+	}{
+		{name: "server error", statusCode: http.StatusBadGateway, expected: true},
+		{name: "too many requests", statusCode: http.StatusTooManyRequests, expected: true},
+		{name: "client error", statusCode: http.StatusNotFound, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, httpfeed.StatusError{StatusCode: tc.statusCode}.Temporary())
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package httpfeed
+
+type Option func(*fetcher)
+
+// WithMaxBodySize sets the max size of a feed document in bytes. Larger documents
+// fail with ErrBodyTooLarge rather than being read into memory.
+func WithMaxBodySize(size int64) Option {
+	return func(f *fetcher) {
+		f.maxBodySize = size
+	}
+}
+
+// WithMaxItems sets the max number of items returned for a feed. Items after the
+// limit are dropped in document order, without being decoded.
+func WithMaxItems(items int) Option {
+	return func(f *fetcher) {
+		f.maxItems = items
+	}
+}
@@ -0,0 +1,170 @@
+package jsonfeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/provider/httpfeed"
+)
+
+const (
+	fieldItems = "items"
+
+	// DefaultURLTemplate is used to build feed URLs when no template is configured.
+	DefaultURLTemplate = "{base}/{category}/feed.json"
+
+	versionPrefix = "https://jsonfeed.org/version/"
+)
+
+type (
+	fetcher interface {
+		Fetch(ctx context.Context, category news.Category, decode httpfeed.Decoder) (*news.Feed, error)
+	}
+
+	adapter struct {
+		provider    news.Provider
+		fetcher     fetcher
+		urlTemplate string
+		fetcherOpts []httpfeed.Option
+	}
+)
+
+func New(provider news.Provider, url string, client *http.Client, opts ...Option) (*adapter, error) {
+	if provider == "" {
+		return nil, news.InvalidParameterError{Parameter: "provider"}
+	}
+
+	a := &adapter{
+		provider:    provider,
+		urlTemplate: DefaultURLTemplate,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	var err error
+	a.fetcher, err = httpfeed.New(url, client, a.urlTemplate, a.fetcherOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *adapter) GetFeed(ctx context.Context, category news.Category) (*news.Feed, error) {
+	return a.fetcher.Fetch(ctx, category, a.decode)
+}
+
+func (a *adapter) decode(ctx context.Context, r io.Reader, category news.Category, maxItems int) (*news.Feed, error) {
+	response, err := decodeResponse(r, maxItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal body: %v", err)
+	}
+
+	if !strings.HasPrefix(response.Version, versionPrefix) {
+		return nil, fmt.Errorf("unsupported feed version: %q", response.Version)
+	}
+
+	return response.toFeed(ctx, a.provider, category), nil
+}
+
+// decodeResponse decodes a feed document, keeping its first maxItems items. The items
+// are streamed, so those after maxItems are skipped without being held in memory.
+func decodeResponse(r io.Reader, maxItems int) (*Response, error) {
+	d := json.NewDecoder(r)
+	if err := expectDelim(d, '{'); err != nil {
+		return nil, err
+	}
+
+	var (
+		items  []Item
+		fields = make(map[string]json.RawMessage)
+	)
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := t.(string)
+
+		if key != fieldItems {
+			var field json.RawMessage
+			if err := d.Decode(&field); err != nil {
+				return nil, err
+			}
+			fields[key] = field
+			continue
+		}
+
+		if items, err = decodeItems(d, maxItems); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expectDelim(d, '}'); err != nil {
+		return nil, err
+	}
+
+	// the rest of the document is small, so it's decoded once the items are removed
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var response Response
+	if err := json.Unmarshal(b, &response); err != nil {
+		return nil, err
+	}
+	response.Items = items
+
+	return &response, nil
+}
+
+// decodeItems decodes the first maxItems items of an array, skipping the rest.
+func decodeItems(d *json.Decoder, maxItems int) ([]Item, error) {
+	t, err := d.Token()
+	switch {
+	case err != nil:
+		return nil, err
+	case t == nil:
+		return nil, nil
+	case t != json.Delim('['):
+		return nil, fmt.Errorf("items is %v, not an array", t)
+	}
+
+	var items []Item
+	for d.More() {
+		if len(items) == maxItems {
+			var skipped struct{}
+			if err := d.Decode(&skipped); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var item Item
+		if err := d.Decode(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, expectDelim(d, ']')
+}
+
+func expectDelim(d *json.Decoder, delim json.Delim) error {
+	t, err := d.Token()
+	switch {
+	case err != nil:
+		return err
+	case t != delim:
+		return fmt.Errorf("expected %v, found %v", delim, t)
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package jsonfeed_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/news"
+	service "github.com/cshep4/news-api/internal/news/service"
+	"github.com/cshep4/news-api/internal/provider/jsonfeed"
+)
+
+const provider = news.Provider("blog")
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+type errorRoundTripper struct{ err error }
+
+func (e errorRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, e.err
+}
+
+func TestNew_Error(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		provider               news.Provider
+		url                    string
+		client                 *http.Client
+		opts                   []jsonfeed.Option
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "provider is empty",
+			provider:               "",
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			expectedErrorParameter: "provider",
+		},
+		{
+			name:                   "url is empty",
+			provider:               provider,
+			url:                    "",
+			expectedErrorParameter: "url",
+		},
+		{
+			name:                   "client is invalid",
+			provider:               provider,
+			url:                    "https://test.com",
+			client:                 nil,
+			expectedErrorParameter: "client",
+		},
+		{
+			name:                   "url template has no category",
+			provider:               provider,
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			opts:                   []jsonfeed.Option{jsonfeed.WithURLTemplate("{base}/feed.json")},
+			expectedErrorParameter: "urlTemplate",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter, err := jsonfeed.New(tc.provider, tc.url, tc.client, tc.opts...)
+			require.Error(t, err)
+			require.Nil(t, adapter)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestNew_Success(t *testing.T) {
+	testCases := []struct {
+		name     string
+		provider news.Provider
+		url      string
+		client   *http.Client
+	}{
+		{
+			name:     "successfully create adapter",
+			provider: provider,
+			url:      "test url",
+			client:   &http.Client{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter, err := jsonfeed.New(tc.provider, tc.url, tc.client)
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			assert.Implements(t, (*service.Provider)(nil), adapter)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_Error(t *testing.T) {
+	testCases := []struct {
+		name       string
+		client     *http.Client
+		statusCode int
+		body       string
+		expectedEr string
+	}{
+		{
+			name: "request error",
+			client: &http.Client{
+				Transport: errorRoundTripper{err: testError("error")},
+			},
+			statusCode: http.StatusOK,
+			expectedEr: "failed to do request",
+		},
+		{
+			name:       "status code not 200",
+			statusCode: http.StatusTeapot,
+			expectedEr: "unexpected status code",
+		},
+		{
+			name:       "invalid response body",
+			statusCode: http.StatusOK,
+			expectedEr: "failed to unmarshal body",
+		},
+		{
+			name:       "unsupported version",
+			statusCode: http.StatusOK,
+			body:       `{"version": "1", "items": []}`,
+			expectedEr: "unsupported feed version",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statusCode)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer s.Close()
+
+			if tc.client == nil {
+				tc.client = s.Client()
+			}
+
+			adapter, err := jsonfeed.New(provider, s.URL, tc.client)
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			_, err = adapter.GetFeed(context.Background(), "category")
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), tc.expectedEr)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_Success(t *testing.T) {
+	const (
+		version     = "https://jsonfeed.org/version/1.1"
+		title       = "title"
+		description = "description"
+		link        = "link"
+		imageURL    = "image url"
+		language    = "language"
+	)
+
+	now := time.Now().UTC().Round(time.Second)
+
+	testCases := []struct {
+		name           string
+		opts           []jsonfeed.Option
+		expectedPath   string
+		apiResponse    jsonfeed.Response
+		expectedResult *news.Feed
+	}{
+		{
+			name:         "successful request",
+			expectedPath: "/category/feed.json",
+			apiResponse: jsonfeed.Response{
+				Version:     version,
+				Title:       title,
+				HomePageURL: link,
+				Description: description,
+				Language:    language,
+				Items: []jsonfeed.Item{
+					{
+						ID:            "1",
+						URL:           link,
+						Title:         title,
+						ContentText:   description,
+						Summary:       "summary",
+						Image:         imageURL,
+						BannerImage:   "banner image",
+						DatePublished: now.Format(time.RFC3339),
+						Authors:       []jsonfeed.Author{{Name: "author 1"}, {URL: "no name"}, {Name: "author 2"}},
+						Author:        &jsonfeed.Author{Name: "ignored"},
+						Tags:          []string{"politics"},
+						Attachments: []jsonfeed.Attachment{
+							{URL: "audio url", MimeType: "audio/mpeg", SizeInBytes: 2048},
+						},
+					},
+				},
+			},
+			expectedResult: &news.Feed{
+				Title:       title,
+				Description: description,
+				Link:        link,
+				Language:    language,
+				Items: []news.Item{
+					{
+						Category:    "category",
+						Provider:    provider,
+						Title:       title,
+						Link:        link,
+						Description: description,
+						Thumbnail:   imageURL,
+						DateTime:    now,
+						GUID:        "1",
+						Authors:     []string{"author 1", "author 2"},
+						Tags:        []string{"politics"},
+						Enclosures:  []news.Enclosure{{URL: "audio url", Type: "audio/mpeg", Length: 2048}},
+					},
+				},
+			},
+		},
+		{
+			name:         "fallback fields",
+			opts:         []jsonfeed.Option{jsonfeed.WithURLTemplate("{base}/{category}.json")},
+			expectedPath: "/category.json",
+			apiResponse: jsonfeed.Response{
+				Version: version,
+				Items: []jsonfeed.Item{
+					{
+						ID:           "1",
+						ExternalURL:  link,
+						Title:        title,
+						Summary:      description,
+						BannerImage:  imageURL,
+						DateModified: now.Format(time.RFC3339),
+						Author:       &jsonfeed.Author{Name: "author"},
+					},
+				},
+			},
+			expectedResult: &news.Feed{
+				Items: []news.Item{
+					{
+						Category:    "category",
+						Provider:    provider,
+						Title:       title,
+						Link:        link,
+						Description: description,
+						Thumbnail:   imageURL,
+						DateTime:    now,
+						GUID:        "1",
+						Authors:     []string{"author"},
+					},
+				},
+			},
+		},
+		{
+			name:         "skip items with invalid or missing dates",
+			expectedPath: "/category/feed.json",
+			apiResponse: jsonfeed.Response{
+				Version: version,
+				Items: []jsonfeed.Item{
+					{
+						ID:            "1",
+						Title:         title,
+						DatePublished: "invalid",
+					},
+					{
+						ID:            "2",
+						Title:         title,
+						DatePublished: now.Format(time.RFC1123Z),
+					},
+					{
+						ID:    "3",
+						Title: title,
+					},
+				},
+			},
+			expectedResult: &news.Feed{
+				Items: []news.Item{
+					{
+						Category: "category",
+						Provider: provider,
+						Title:    title,
+						DateTime: now,
+						GUID:     "2",
+					},
+				},
+				SkippedItems: 2,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.expectedPath, r.URL.Path)
+				w.WriteHeader(http.StatusOK)
+				require.NoError(t, json.NewEncoder(w).Encode(tc.apiResponse))
+			}))
+			defer s.Close()
+
+			adapter, err := jsonfeed.New(provider, s.URL, s.Client(), tc.opts...)
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			res, err := adapter.GetFeed(context.Background(), "category")
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_MaxItems(t *testing.T) {
+	const maxItems = 10
+
+	// a document of about 9MB, which mostly holds untitled items, with the version after them
+	var b strings.Builder
+	b.WriteString(`{"title": "title", "items": [`)
+	for i := 0; i < maxItems; i++ {
+		fmt.Fprintf(&b, `{"id": "%d", "date_published": "2021-02-06T20:47:21Z"},`, i)
+	}
+	for b.Len() < 9<<20 {
+		b.WriteString(`{"title": "untitled item"},`)
+	}
+	b.WriteString(`{}], "version": "https://jsonfeed.org/version/1.1"}`)
+	body := []byte(b.String())
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer s.Close()
+
+	adapter, err := jsonfeed.New(provider, s.URL, s.Client(), jsonfeed.WithMaxItems(maxItems))
+	require.NoError(t, err)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	res, err := adapter.GetFeed(context.Background(), "category")
+	require.NoError(t, err)
+
+	runtime.ReadMemStats(&after)
+
+	assert.Len(t, res.Items, maxItems)
+	assert.Equal(t, "title", res.Title)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+}
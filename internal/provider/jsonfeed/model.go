@@ -0,0 +1,136 @@
+package jsonfeed
+
+import (
+	"context"
+
+	"github.com/cshep4/news-api/internal/log"
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/provider/datetime"
+)
+
+type (
+	Response struct {
+		Version     string `json:"version"`
+		Title       string `json:"title"`
+		HomePageURL string `json:"home_page_url,omitempty"`
+		FeedURL     string `json:"feed_url,omitempty"`
+		Description string `json:"description,omitempty"`
+		Icon        string `json:"icon,omitempty"`
+		Favicon     string `json:"favicon,omitempty"`
+		Language    string `json:"language,omitempty"`
+		Items       []Item `json:"items"`
+	}
+
+	Item struct {
+		ID            string       `json:"id"`
+		URL           string       `json:"url,omitempty"`
+		ExternalURL   string       `json:"external_url,omitempty"`
+		Title         string       `json:"title,omitempty"`
+		ContentHTML   string       `json:"content_html,omitempty"`
+		ContentText   string       `json:"content_text,omitempty"`
+		Summary       string       `json:"summary,omitempty"`
+		Image         string       `json:"image,omitempty"`
+		BannerImage   string       `json:"banner_image,omitempty"`
+		DatePublished string       `json:"date_published,omitempty"`
+		DateModified  string       `json:"date_modified,omitempty"`
+		Tags          []string     `json:"tags,omitempty"`
+		Language      string       `json:"language,omitempty"`
+		Authors       []Author     `json:"authors,omitempty"`
+		Author        *Author      `json:"author,omitempty"`
+		Attachments   []Attachment `json:"attachments,omitempty"`
+	}
+
+	Author struct {
+		Name   string `json:"name,omitempty"`
+		URL    string `json:"url,omitempty"`
+		Avatar string `json:"avatar,omitempty"`
+	}
+
+	Attachment struct {
+		URL         string `json:"url"`
+		MimeType    string `json:"mime_type"`
+		Title       string `json:"title,omitempty"`
+		SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+	}
+)
+
+func (r *Response) toFeed(ctx context.Context, provider news.Provider, category news.Category) *news.Feed {
+	var (
+		items   []news.Item
+		skipped int
+	)
+	for _, i := range r.Items {
+		dateTime, err := datetime.Parse(firstOf(i.DatePublished, i.DateModified))
+		if err != nil {
+			log.Info(ctx, "feed_item_skipped",
+				log.SafeParam("provider", provider),
+				log.SafeParam("category", category),
+				log.SafeParam("id", i.ID),
+				log.ErrorParam(err),
+			)
+			skipped++
+			continue
+		}
+
+		items = append(items, news.Item{
+			Category:    category,
+			Provider:    provider,
+			Title:       i.Title,
+			Link:        firstOf(i.URL, i.ExternalURL),
+			Description: firstOf(i.ContentText, i.Summary),
+			Thumbnail:   firstOf(i.Image, i.BannerImage),
+			DateTime:    dateTime,
+			GUID:        firstOf(i.ID, i.URL, i.ExternalURL),
+			Authors:     i.authors(),
+			Tags:        i.Tags,
+			Enclosures:  i.enclosures(),
+		})
+	}
+
+	return &news.Feed{
+		Title:        r.Title,
+		Description:  r.Description,
+		Link:         r.HomePageURL,
+		Language:     r.Language,
+		Items:        items,
+		SkippedItems: skipped,
+	}
+}
+
+// authors returns the names of the item's authors, using the author field of
+// version 1.0 feeds when authors isn't set.
+func (i Item) authors() []string {
+	authors := i.Authors
+	if len(authors) == 0 && i.Author != nil {
+		authors = []Author{*i.Author}
+	}
+
+	var names []string
+	for _, a := range authors {
+		if a.Name != "" {
+			names = append(names, a.Name)
+		}
+	}
+
+	return names
+}
+
+func (i Item) enclosures() []news.Enclosure {
+	var enclosures []news.Enclosure
+	for _, a := range i.Attachments {
+		if a.URL != "" {
+			enclosures = append(enclosures, news.Enclosure{URL: a.URL, Type: a.MimeType, Length: a.SizeInBytes})
+		}
+	}
+
+	return enclosures
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
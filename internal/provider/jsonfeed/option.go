@@ -0,0 +1,29 @@
+package jsonfeed
+
+import "github.com/cshep4/news-api/internal/provider/httpfeed"
+
+type Option func(*adapter)
+
+// WithURLTemplate sets the template used to build the feed URL for a category,
+// e.g. "{base}/{category}.json". The template must contain "{category}".
+func WithURLTemplate(template string) Option {
+	return func(a *adapter) {
+		a.urlTemplate = template
+	}
+}
+
+// WithMaxBodySize sets the max size of a feed document in bytes, defaulting to
+// httpfeed.DefaultMaxBodySize.
+func WithMaxBodySize(size int64) Option {
+	return func(a *adapter) {
+		a.fetcherOpts = append(a.fetcherOpts, httpfeed.WithMaxBodySize(size))
+	}
+}
+
+// WithMaxItems sets the max number of items returned for a feed, defaulting to
+// httpfeed.DefaultMaxItems.
+func WithMaxItems(items int) Option {
+	return func(a *adapter) {
+		a.fetcherOpts = append(a.fetcherOpts, httpfeed.WithMaxItems(items))
+	}
+}
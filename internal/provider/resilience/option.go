@@ -0,0 +1,30 @@
+package resilience
+
+import "time"
+
+type Option func(*provider)
+
+// WithRetries sets the number of times a retryable error is retried.
+func WithRetries(retries int) Option {
+	return func(p *provider) {
+		p.retries = retries
+	}
+}
+
+// WithBackoff sets the backoff before the first retry, which doubles for each
+// subsequent retry up to max.
+func WithBackoff(backoff, max time.Duration) Option {
+	return func(p *provider) {
+		p.backoff = backoff
+		p.maxBackoff = max
+	}
+}
+
+// WithCircuitBreaker sets the number of consecutive failures after which the
+// circuit opens, and how long it stays open before a trial call is allowed.
+func WithCircuitBreaker(failureThreshold int, openTimeout time.Duration) Option {
+	return func(p *provider) {
+		p.failureThreshold = failureThreshold
+		p.openTimeout = openTimeout
+	}
+}
@@ -0,0 +1,263 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/cshep4/news-api/internal/log"
+	"github.com/cshep4/news-api/internal/news"
+)
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half_open"
+
+	defaultBackoff          = 100 * time.Millisecond
+	defaultMaxBackoff       = time.Second
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+)
+
+var ErrCircuitOpen = fmt.Errorf("circuit breaker is open: %w", news.ErrProviderUnavailable)
+
+type (
+	// State is the state of a provider's circuit breaker.
+	State string
+
+	Provider interface {
+		GetFeed(ctx context.Context, category news.Category) (*news.Feed, error)
+	}
+
+	provider struct {
+		name             news.Provider
+		provider         Provider
+		clock            clockwork.Clock
+		retries          int
+		backoff          time.Duration
+		maxBackoff       time.Duration
+		failureThreshold int
+		openTimeout      time.Duration
+
+		mutex    sync.Mutex
+		random   *rand.Rand
+		state    State
+		failures int
+		openedAt time.Time
+		trial    bool
+	}
+)
+
+// New wraps provider with retries and a circuit breaker. Retryable errors (5xx
+// responses and timeouts) are retried with jittered exponential backoff. The
+// circuit opens after a number of consecutive failed calls, failing fast until
+// the open timeout has passed, after which a single trial call is let through. Only
+// retryable errors and failures to reach the upstream count as failed calls, as
+// other errors, such as a 404 response, mean the upstream is up.
+func New(name news.Provider, p Provider, clock clockwork.Clock, opts ...Option) (*provider, error) {
+	switch {
+	case name == "":
+		return nil, news.InvalidParameterError{Parameter: "name"}
+	case p == nil:
+		return nil, news.InvalidParameterError{Parameter: "provider"}
+	case clock == nil:
+		return nil, news.InvalidParameterError{Parameter: "clock"}
+	}
+
+	r := &provider{
+		name:             name,
+		provider:         p,
+		clock:            clock,
+		backoff:          defaultBackoff,
+		maxBackoff:       defaultMaxBackoff,
+		failureThreshold: defaultFailureThreshold,
+		openTimeout:      defaultOpenTimeout,
+		random:           rand.New(rand.NewSource(clock.Now().UnixNano())),
+		state:            StateClosed,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	switch {
+	case r.retries < 0:
+		return nil, news.InvalidParameterError{Parameter: "retries"}
+	case r.backoff < 0 || r.maxBackoff < r.backoff:
+		return nil, news.InvalidParameterError{Parameter: "backoff"}
+	case r.failureThreshold < 1:
+		return nil, news.InvalidParameterError{Parameter: "failureThreshold"}
+	case r.openTimeout <= 0:
+		return nil, news.InvalidParameterError{Parameter: "openTimeout"}
+	}
+
+	return r, nil
+}
+
+// State returns the current state of the circuit breaker.
+func (p *provider) State() State {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.state == StateOpen && p.clock.Since(p.openedAt) >= p.openTimeout {
+		return StateHalfOpen
+	}
+
+	return p.state
+}
+
+func (p *provider) GetFeed(ctx context.Context, category news.Category) (*news.Feed, error) {
+	if !p.allow(ctx) {
+		return nil, fmt.Errorf("%s: %w", p.name, ErrCircuitOpen)
+	}
+
+	feed, err := p.getFeed(ctx, category)
+	if err != nil && ctx.Err() != nil {
+		// the caller gave up, which says nothing about the health of the provider
+		p.release()
+		return nil, err
+	}
+
+	p.record(ctx, err)
+
+	return feed, err
+}
+
+func (p *provider) getFeed(ctx context.Context, category news.Category) (*news.Feed, error) {
+	for attempt := 0; ; attempt++ {
+		feed, err := p.provider.GetFeed(ctx, category)
+		if err == nil || attempt >= p.retries || !retryable(err) || ctx.Err() != nil {
+			return feed, err
+		}
+
+		log.Info(ctx, "retrying_provider_request",
+			log.SafeParam("provider", p.name),
+			log.SafeParam("category", category),
+			log.SafeParam("attempt", attempt+1),
+			log.ErrorParam(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-p.clock.After(p.delay(attempt)):
+		}
+	}
+}
+
+// delay returns the backoff before retrying the given attempt, which doubles with
+// each attempt up to the max backoff, with jitter of up to half of the backoff.
+func (p *provider) delay(attempt int) time.Duration {
+	d := p.backoff
+	for i := 0; i < attempt && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.maxBackoff {
+		d = p.maxBackoff
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	half := int64(d / 2)
+	if half == 0 {
+		return d
+	}
+
+	return time.Duration(half + p.random.Int63n(half))
+}
+
+// allow reports whether a call can be made. When the open timeout has passed, a
+// single trial call is allowed while the circuit is half open.
+func (p *provider) allow(ctx context.Context) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	switch p.state {
+	case StateClosed:
+		return true
+	case StateOpen:
+		if p.clock.Since(p.openedAt) < p.openTimeout {
+			return false
+		}
+		p.transition(ctx, StateHalfOpen)
+	}
+
+	if p.trial {
+		return false
+	}
+	p.trial = true
+
+	return true
+}
+
+// release gives up a trial call without recording a result.
+func (p *provider) release() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.trial = false
+}
+
+func (p *provider) record(ctx context.Context, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.trial = false
+
+	if !failure(err) {
+		p.failures = 0
+		p.transition(ctx, StateClosed)
+		return
+	}
+
+	p.failures++
+	if p.state == StateHalfOpen || p.failures >= p.failureThreshold {
+		p.openedAt = p.clock.Now()
+		p.transition(ctx, StateOpen)
+	}
+}
+
+func (p *provider) transition(ctx context.Context, state State) {
+	if p.state == state {
+		return
+	}
+
+	log.Info(ctx, "circuit_breaker_state_changed",
+		log.SafeParam("provider", p.name),
+		log.SafeParam("from", p.state),
+		log.SafeParam("to", state),
+	)
+
+	p.state = state
+}
+
+// failure reports whether err says the upstream is unhealthy, either because it's
+// retryable or because the upstream couldn't be reached.
+func failure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	return retryable(err) || errors.As(err, &netErr)
+}
+
+// retryable reports whether err is a timeout or a temporary failure, such as a
+// 5xx response from the upstream.
+func retryable(err error) bool {
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
@@ -0,0 +1,319 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider_mock "github.com/cshep4/news-api/internal/mock/provider"
+	"github.com/cshep4/news-api/internal/news"
+	service "github.com/cshep4/news-api/internal/news/service"
+	"github.com/cshep4/news-api/internal/provider/httpfeed"
+	"github.com/cshep4/news-api/internal/provider/resilience"
+)
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary" }
+func (temporaryError) Temporary() bool { return true }
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+func TestNew_Error(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		providerName           news.Provider
+		provider               resilience.Provider
+		clock                  clockwork.Clock
+		opts                   []resilience.Option
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "name is empty",
+			provider:               provider_mock.NewMockProvider(nil),
+			clock:                  clockwork.NewFakeClock(),
+			expectedErrorParameter: "name",
+		},
+		{
+			name:                   "provider is empty",
+			providerName:           news.ProviderBBC,
+			clock:                  clockwork.NewFakeClock(),
+			expectedErrorParameter: "provider",
+		},
+		{
+			name:                   "clock is empty",
+			providerName:           news.ProviderBBC,
+			provider:               provider_mock.NewMockProvider(nil),
+			expectedErrorParameter: "clock",
+		},
+		{
+			name:                   "negative retries",
+			providerName:           news.ProviderBBC,
+			provider:               provider_mock.NewMockProvider(nil),
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []resilience.Option{resilience.WithRetries(-1)},
+			expectedErrorParameter: "retries",
+		},
+		{
+			name:                   "max backoff less than backoff",
+			providerName:           news.ProviderBBC,
+			provider:               provider_mock.NewMockProvider(nil),
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []resilience.Option{resilience.WithBackoff(time.Second, time.Millisecond)},
+			expectedErrorParameter: "backoff",
+		},
+		{
+			name:                   "invalid failure threshold",
+			providerName:           news.ProviderBBC,
+			provider:               provider_mock.NewMockProvider(nil),
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []resilience.Option{resilience.WithCircuitBreaker(0, time.Second)},
+			expectedErrorParameter: "failureThreshold",
+		},
+		{
+			name:                   "invalid open timeout",
+			providerName:           news.ProviderBBC,
+			provider:               provider_mock.NewMockProvider(nil),
+			clock:                  clockwork.NewFakeClock(),
+			opts:                   []resilience.Option{resilience.WithCircuitBreaker(1, 0)},
+			expectedErrorParameter: "openTimeout",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := resilience.New(tc.providerName, tc.provider, tc.clock, tc.opts...)
+			require.Error(t, err)
+			require.Nil(t, provider)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestNew_Success(t *testing.T) {
+	provider, err := resilience.New(news.ProviderBBC, provider_mock.NewMockProvider(nil), clockwork.NewFakeClock())
+	require.NoError(t, err)
+	require.NotNil(t, provider)
+
+	assert.Implements(t, (*service.Provider)(nil), provider)
+	assert.Equal(t, resilience.StateClosed, provider.State())
+}
+
+func TestProvider_GetFeed_Retry(t *testing.T) {
+	feed := &news.Feed{Title: "feed"}
+
+	testCases := []struct {
+		name          string
+		errs          []error
+		retries       int
+		expectedCalls int
+		expectedFeed  *news.Feed
+		expectedErr   error
+	}{
+		{
+			name:          "success",
+			errs:          []error{nil},
+			retries:       2,
+			expectedCalls: 1,
+			expectedFeed:  feed,
+		},
+		{
+			name:          "retry temporary error",
+			errs:          []error{temporaryError{}, nil},
+			retries:       2,
+			expectedCalls: 2,
+			expectedFeed:  feed,
+		},
+		{
+			name:          "retry timeout",
+			errs:          []error{timeoutError{}, nil},
+			retries:       2,
+			expectedCalls: 2,
+			expectedFeed:  feed,
+		},
+		{
+			name:          "do not retry permanent error",
+			errs:          []error{testError("error")},
+			retries:       2,
+			expectedCalls: 1,
+			expectedErr:   testError("error"),
+		},
+		{
+			name:          "retries exhausted",
+			errs:          []error{temporaryError{}, temporaryError{}, temporaryError{}},
+			retries:       2,
+			expectedCalls: 3,
+			expectedErr:   temporaryError{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, ctx := gomock.WithContext(context.Background(), t)
+			defer ctrl.Finish()
+
+			mock := provider_mock.NewMockProvider(ctrl)
+
+			var calls int
+			mock.EXPECT().
+				GetFeed(ctx, news.CategoryUK).
+				DoAndReturn(func(context.Context, news.Category) (*news.Feed, error) {
+					err := tc.errs[calls]
+					calls++
+					if err != nil {
+						return nil, err
+					}
+					return feed, nil
+				}).
+				Times(tc.expectedCalls)
+
+			provider, err := resilience.New(news.ProviderBBC, mock, clockwork.NewFakeClock(),
+				resilience.WithRetries(tc.retries),
+				resilience.WithBackoff(0, 0),
+			)
+			require.NoError(t, err)
+
+			res, err := provider.GetFeed(ctx, news.CategoryUK)
+			if tc.expectedErr != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tc.expectedErr))
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedFeed, res)
+		})
+	}
+}
+
+func TestProvider_GetFeed_Backoff(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	clock := clockwork.NewFakeClock()
+	mock := provider_mock.NewMockProvider(ctrl)
+
+	gomock.InOrder(
+		mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(nil, temporaryError{}),
+		mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(&news.Feed{}, nil),
+	)
+
+	provider, err := resilience.New(news.ProviderBBC, mock, clock,
+		resilience.WithRetries(1),
+		resilience.WithBackoff(time.Second, time.Second),
+	)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := provider.GetFeed(ctx, news.CategoryUK)
+		assert.NoError(t, err)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	wg.Wait()
+}
+
+func TestProvider_GetFeed_CircuitBreaker(t *testing.T) {
+	const openTimeout = time.Minute
+
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	clock := clockwork.NewFakeClock()
+	mock := provider_mock.NewMockProvider(ctrl)
+
+	provider, err := resilience.New(news.ProviderBBC, mock, clock,
+		resilience.WithCircuitBreaker(2, openTimeout),
+	)
+	require.NoError(t, err)
+
+	mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(nil, temporaryError{}).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := provider.GetFeed(ctx, news.CategoryUK)
+		require.Error(t, err)
+	}
+	assert.Equal(t, resilience.StateOpen, provider.State())
+
+	_, err = provider.GetFeed(ctx, news.CategoryUK)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, resilience.ErrCircuitOpen))
+
+	clock.Advance(openTimeout)
+	assert.Equal(t, resilience.StateHalfOpen, provider.State())
+
+	mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(nil, temporaryError{})
+	_, err = provider.GetFeed(ctx, news.CategoryUK)
+	require.Error(t, err)
+	assert.Equal(t, resilience.StateOpen, provider.State())
+
+	clock.Advance(openTimeout)
+
+	mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(&news.Feed{}, nil)
+	_, err = provider.GetFeed(ctx, news.CategoryUK)
+	require.NoError(t, err)
+	assert.Equal(t, resilience.StateClosed, provider.State())
+}
+
+func TestProvider_GetFeed_CircuitBreaker_PermanentErrors(t *testing.T) {
+	ctrl, ctx := gomock.WithContext(context.Background(), t)
+	defer ctrl.Finish()
+
+	mock := provider_mock.NewMockProvider(ctrl)
+
+	provider, err := resilience.New(news.ProviderBBC, mock, clockwork.NewFakeClock(),
+		resilience.WithCircuitBreaker(2, time.Minute),
+	)
+	require.NoError(t, err)
+
+	// a 404 means the upstream is up, so repeated 404s don't open the circuit
+	notFound := httpfeed.StatusError{StatusCode: http.StatusNotFound}
+	mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(nil, notFound).Times(3)
+	for i := 0; i < 3; i++ {
+		_, err := provider.GetFeed(ctx, news.CategoryUK)
+		require.Equal(t, notFound, err)
+	}
+	assert.Equal(t, resilience.StateClosed, provider.State())
+
+	// and they reset the failures of an upstream that couldn't be reached
+	unreachable := &url.Error{Op: "Get", URL: "http://localhost", Err: testError("connection refused")}
+	gomock.InOrder(
+		mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(nil, unreachable),
+		mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(nil, notFound),
+		mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(nil, unreachable),
+	)
+	for i := 0; i < 3; i++ {
+		_, err := provider.GetFeed(ctx, news.CategoryUK)
+		require.Error(t, err)
+	}
+	assert.Equal(t, resilience.StateClosed, provider.State())
+
+	mock.EXPECT().GetFeed(ctx, news.CategoryUK).Return(nil, unreachable)
+	_, err = provider.GetFeed(ctx, news.CategoryUK)
+	require.Error(t, err)
+	assert.Equal(t, resilience.StateOpen, provider.State())
+}
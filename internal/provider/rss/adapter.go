@@ -0,0 +1,186 @@
+package rss
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/provider/httpfeed"
+)
+
+const (
+	// DefaultURLTemplate is used to build feed URLs when no template is configured.
+	DefaultURLTemplate = "{base}/{category}/rss.xml"
+
+	rootRSS  = "rss"
+	rootAtom = "feed"
+
+	elementChannel = "channel"
+	elementItem    = "item"
+	elementEntry   = "entry"
+)
+
+type (
+	fetcher interface {
+		Fetch(ctx context.Context, category news.Category, decode httpfeed.Decoder) (*news.Feed, error)
+	}
+
+	adapter struct {
+		provider    news.Provider
+		fetcher     fetcher
+		urlTemplate string
+		fetcherOpts []httpfeed.Option
+		thumbnail   []Thumbnail
+		// thumbnailWidth is the preferred width of item thumbnails, 0 uses the first image.
+		thumbnailWidth int
+	}
+
+	// itemLimiter reads the tokens of a feed document until the element after the max
+	// number of items starts, then closes the open elements and ends the document, so
+	// that the rest of it isn't read.
+	itemLimiter struct {
+		d        *xml.Decoder
+		maxItems int
+		items    int
+		open     []xml.Name
+		done     bool
+	}
+)
+
+func New(provider news.Provider, url string, client *http.Client, opts ...Option) (*adapter, error) {
+	if provider == "" {
+		return nil, news.InvalidParameterError{Parameter: "provider"}
+	}
+
+	a := &adapter{
+		provider:    provider,
+		urlTemplate: DefaultURLTemplate,
+		thumbnail:   defaultThumbnail,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.thumbnailWidth < 0 {
+		return nil, news.InvalidParameterError{Parameter: "thumbnailWidth"}
+	}
+
+	for _, t := range a.thumbnail {
+		if !t.valid() {
+			return nil, news.InvalidParameterError{Parameter: "thumbnail"}
+		}
+	}
+
+	var err error
+	a.fetcher, err = httpfeed.New(url, client, a.urlTemplate, a.fetcherOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *adapter) GetFeed(ctx context.Context, category news.Category) (*news.Feed, error) {
+	return a.fetcher.Fetch(ctx, category, a.decode)
+}
+
+// decode detects the feed format from the root element and maps it to a feed. The
+// document stops being read after maxItems items.
+func (a *adapter) decode(ctx context.Context, r io.Reader, category news.Category, maxItems int) (*news.Feed, error) {
+	raw := xml.NewDecoder(r)
+	raw.CharsetReader = charsetReader
+
+	d := xml.NewTokenDecoder(&itemLimiter{d: raw, maxItems: maxItems})
+
+	start, err := rootElement(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal body: %v", err)
+	}
+
+	switch start.Name.Local {
+	case rootRSS:
+		var response Response
+		if err := d.DecodeElement(&response, &start); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal body: %v", err)
+		}
+		return response.toFeed(ctx, a.provider, category, a.thumbnail, a.thumbnailWidth), nil
+	case rootAtom:
+		var feed AtomFeed
+		if err := d.DecodeElement(&feed, &start); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal body: %v", err)
+		}
+		return feed.toFeed(ctx, a.provider, category, a.thumbnail, a.thumbnailWidth), nil
+	}
+
+	return nil, fmt.Errorf("unsupported feed format: %s", start.Name.Local)
+}
+
+func rootElement(d *xml.Decoder) (xml.StartElement, error) {
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+
+		if start, ok := t.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// Token returns the next raw token of the document, leaving the namespaces to be
+// translated by the decoder reading from the limiter.
+func (l *itemLimiter) Token() (xml.Token, error) {
+	if l.done {
+		if len(l.open) == 0 {
+			return nil, io.EOF
+		}
+
+		name := l.open[len(l.open)-1]
+		l.open = l.open[:len(l.open)-1]
+		return xml.EndElement{Name: name}, nil
+	}
+
+	t, err := l.d.RawToken()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := t.(type) {
+	case xml.StartElement:
+		if l.isItem(t.Name) {
+			if l.items == l.maxItems {
+				l.done = true
+				return l.Token()
+			}
+			l.items++
+		}
+		l.open = append(l.open, t.Name)
+	case xml.EndElement:
+		if len(l.open) > 0 {
+			l.open = l.open[:len(l.open)-1]
+		}
+	}
+
+	return t, nil
+}
+
+// isItem reports whether an element named name, starting at the current position,
+// is an RSS item or an Atom entry.
+func (l *itemLimiter) isItem(name xml.Name) bool {
+	if len(l.open) == 0 {
+		return false
+	}
+
+	switch parent := l.open[len(l.open)-1].Local; name.Local {
+	case elementItem:
+		return parent == elementChannel
+	case elementEntry:
+		return parent == rootAtom
+	}
+	return false
+}
@@ -0,0 +1,870 @@
+package rss_test
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cshep4/news-api/internal/news"
+	service "github.com/cshep4/news-api/internal/news/service"
+	"github.com/cshep4/news-api/internal/provider/rss"
+)
+
+type testError string
+
+func (e testError) Error() string { return string(e) }
+
+type errorRoundTripper struct{ err error }
+
+func (e errorRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, e.err
+}
+
+func TestNew_Error(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		provider               news.Provider
+		url                    string
+		client                 *http.Client
+		opts                   []rss.Option
+		expectedErrorParameter string
+	}{
+		{
+			name:                   "provider is empty",
+			provider:               "",
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			expectedErrorParameter: "provider",
+		},
+		{
+			name:                   "url is empty",
+			provider:               news.ProviderBBC,
+			url:                    "",
+			expectedErrorParameter: "url",
+		},
+		{
+			name:                   "client is invalid",
+			provider:               news.ProviderBBC,
+			url:                    "https://test.com",
+			client:                 nil,
+			expectedErrorParameter: "client",
+		},
+		{
+			name:                   "url template has no category",
+			provider:               news.ProviderBBC,
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			opts:                   []rss.Option{rss.WithURLTemplate("{base}/rss.xml")},
+			expectedErrorParameter: "urlTemplate",
+		},
+		{
+			name:                   "thumbnail is invalid",
+			provider:               news.ProviderBBC,
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			opts:                   []rss.Option{rss.WithThumbnail("invalid")},
+			expectedErrorParameter: "thumbnail",
+		},
+		{
+			name:                   "max items is invalid",
+			provider:               news.ProviderBBC,
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			opts:                   []rss.Option{rss.WithMaxItems(0)},
+			expectedErrorParameter: "maxItems",
+		},
+		{
+			name:                   "thumbnail width is invalid",
+			provider:               news.ProviderBBC,
+			url:                    "https://test.com",
+			client:                 &http.Client{},
+			opts:                   []rss.Option{rss.WithThumbnailWidth(-1)},
+			expectedErrorParameter: "thumbnailWidth",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter, err := rss.New(tc.provider, tc.url, tc.client, tc.opts...)
+			require.Error(t, err)
+			require.Nil(t, adapter)
+
+			ipe, ok := err.(news.InvalidParameterError)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expectedErrorParameter, ipe.Parameter)
+		})
+	}
+}
+
+func TestNew_Success(t *testing.T) {
+	testCases := []struct {
+		name     string
+		provider news.Provider
+		url      string
+		client   *http.Client
+		opts     []rss.Option
+	}{
+		{
+			name:     "successfully create adapter",
+			provider: news.ProviderBBC,
+			url:      "test url",
+			client:   &http.Client{},
+		},
+		{
+			name:     "successfully create adapter with options",
+			provider: news.ProviderSky,
+			url:      "test url",
+			client:   &http.Client{},
+			opts: []rss.Option{
+				rss.WithURLTemplate("{base}/{category}.xml"),
+				rss.WithThumbnail(rss.ThumbnailMediaThumbnail),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			adapter, err := rss.New(tc.provider, tc.url, tc.client, tc.opts...)
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			assert.Implements(t, (*service.Provider)(nil), adapter)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_Error(t *testing.T) {
+	testCases := []struct {
+		name       string
+		client     *http.Client
+		statusCode int
+		body       string
+		expectedEr string
+	}{
+		{
+			name: "request error",
+			client: &http.Client{
+				Transport: errorRoundTripper{err: testError("error")},
+			},
+			statusCode: http.StatusOK,
+			expectedEr: "failed to do request",
+		},
+		{
+			name:       "status code not 200",
+			statusCode: http.StatusTeapot,
+			expectedEr: "unexpected status code",
+		},
+		{
+			name:       "invalid response body",
+			statusCode: http.StatusOK,
+			expectedEr: "failed to unmarshal body",
+		},
+		{
+			name:       "unsupported feed format",
+			statusCode: http.StatusOK,
+			body:       "<html></html>",
+			expectedEr: "unsupported feed format: html",
+		},
+		{
+			name:       "unsupported charset",
+			statusCode: http.StatusOK,
+			body:       `<?xml version="1.0" encoding="ebcdic"?><rss></rss>`,
+			expectedEr: "unsupported charset: ebcdic",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statusCode)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer s.Close()
+
+			if tc.client == nil {
+				tc.client = s.Client()
+			}
+
+			adapter, err := rss.New(news.ProviderBBC, s.URL, tc.client)
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			_, err = adapter.GetFeed(context.Background(), "category")
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), tc.expectedEr)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_Success(t *testing.T) {
+	const (
+		title       = "title"
+		description = "description"
+		link        = "link"
+		imageURL    = "image url"
+		language    = "language"
+		copyright   = "copyright"
+		ttl         = 1
+	)
+
+	now := time.Now().UTC().Round(time.Second)
+
+	testCases := []struct {
+		name           string
+		provider       news.Provider
+		opts           []rss.Option
+		expectedPath   string
+		apiResponse    rss.Response
+		expectedResult *news.Feed
+	}{
+		{
+			name:     "bbc feed",
+			provider: news.ProviderBBC,
+			opts: []rss.Option{
+				rss.WithURLTemplate("{base}/{category}/rss.xml"),
+				rss.WithThumbnail(rss.ThumbnailMediaThumbnail, rss.ThumbnailChannelImage),
+				rss.WithThumbnailWidth(240),
+			},
+			expectedPath: "/category/rss.xml",
+			apiResponse: rss.Response{
+				Channel: rss.Channel{
+					Title:         title,
+					Description:   description,
+					Link:          link,
+					Image:         rss.Image{URL: imageURL},
+					LastBuildDate: now.Format(time.RFC1123),
+					Copyright:     copyright,
+					Language:      language,
+					TTL:           ttl,
+					Items: []rss.Item{
+						{
+							Title:       title,
+							Description: description,
+							Link:        link,
+							PubDate:     now.Format(time.RFC1123),
+							Thumbnails: []rss.MediaThumbnail{
+								{URL: "small", Width: 120, Height: 68},
+								{URL: "large", Width: 480, Height: 270},
+								{URL: "medium", Width: 240, Height: 135},
+							},
+						},
+						{
+							Title:       title,
+							Description: description,
+							Link:        link,
+							PubDate:     now.Format(time.RFC1123),
+						},
+					},
+				},
+			},
+			expectedResult: &news.Feed{
+				Title:       title,
+				Description: description,
+				Link:        link,
+				Language:    language,
+				Copyright:   copyright,
+				DateTime:    now,
+				TTL:         ttl,
+				Items: []news.Item{
+					{
+						Category:    "category",
+						Provider:    news.ProviderBBC,
+						Title:       title,
+						Link:        link,
+						Description: description,
+						Thumbnail:   "medium",
+						DateTime:    now,
+						GUID:        link,
+						Media: []news.Media{
+							{Kind: news.MediaKindThumbnail, URL: "small", Width: 120, Height: 68},
+							{Kind: news.MediaKindThumbnail, URL: "large", Width: 480, Height: 270},
+							{Kind: news.MediaKindThumbnail, URL: "medium", Width: 240, Height: 135},
+						},
+					},
+					{
+						Category:    "category",
+						Provider:    news.ProviderBBC,
+						Title:       title,
+						Link:        link,
+						Description: description,
+						Thumbnail:   imageURL,
+						DateTime:    now,
+						GUID:        link,
+					},
+				},
+			},
+		},
+		{
+			name:     "sky feed",
+			provider: news.ProviderSky,
+			opts: []rss.Option{
+				rss.WithURLTemplate("{base}/{category}.xml"),
+				rss.WithThumbnail(rss.ThumbnailMediaThumbnail),
+			},
+			expectedPath: "/category.xml",
+			apiResponse: rss.Response{
+				Channel: rss.Channel{
+					Title:         title,
+					Description:   description,
+					Link:          link,
+					LastBuildDate: now.Format(time.RFC1123),
+					Copyright:     copyright,
+					Language:      language,
+					TTL:           ttl,
+					SkipHours:     rss.SkipHours{Hours: []int{1, 24, 25}},
+					SkipDays:      rss.SkipDays{Days: []string{"Saturday", "someday", "Sunday"}},
+					Items: []rss.Item{{
+						Title:       title,
+						Link:        link,
+						Description: description,
+						PubDate:     now.Format(time.RFC1123),
+						Thumbnails:  []rss.MediaThumbnail{{URL: imageURL, Width: 240, Height: 135}},
+					}},
+				},
+			},
+			expectedResult: &news.Feed{
+				Title:       title,
+				Description: description,
+				Link:        link,
+				Language:    language,
+				Copyright:   copyright,
+				DateTime:    now,
+				TTL:         ttl,
+				SkipHours:   []int{1, 0},
+				SkipDays:    []time.Weekday{time.Saturday, time.Sunday},
+				Items: []news.Item{
+					{
+						Category:    "category",
+						Provider:    news.ProviderSky,
+						Title:       title,
+						Link:        link,
+						Description: description,
+						Thumbnail:   imageURL,
+						DateTime:    now,
+						GUID:        link,
+						Media: []news.Media{
+							{Kind: news.MediaKindThumbnail, URL: imageURL, Width: 240, Height: 135},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:         "thumbnail falls back to next field",
+			provider:     news.ProviderBBC,
+			expectedPath: "/category/rss.xml",
+			apiResponse: rss.Response{
+				Channel: rss.Channel{
+					Image: rss.Image{URL: "channel image"},
+					Items: []rss.Item{{
+						Title:      title,
+						PubDate:    now.Format(time.RFC1123),
+						Enclosures: []rss.Enclosure{{URL: imageURL, Type: "image/jpeg", Length: 1024}},
+					}},
+				},
+			},
+			expectedResult: &news.Feed{
+				Items: []news.Item{
+					{
+						Category:   "category",
+						Provider:   news.ProviderBBC,
+						Title:      title,
+						Thumbnail:  imageURL,
+						DateTime:   now,
+						Enclosures: []news.Enclosure{{URL: imageURL, Type: "image/jpeg", Length: 1024}},
+					},
+				},
+			},
+		},
+		{
+			name:         "rich item",
+			provider:     news.ProviderSky,
+			expectedPath: "/category/rss.xml",
+			apiResponse: rss.Response{
+				Channel: rss.Channel{
+					Items: []rss.Item{{
+						Title:      title,
+						Link:       link,
+						Guid:       rss.Guid{Text: "guid", IsPermaLink: "false"},
+						PubDate:    now.Format(time.RFC1123),
+						Creators:   []string{"author 1", "author 2"},
+						Author:     "editor@test.com",
+						Categories: []string{"politics", "uk"},
+						Enclosures: []rss.Enclosure{
+							{URL: "audio url", Type: "audio/mpeg", Length: 2048},
+							{URL: imageURL, Type: "image/jpeg"},
+						},
+						Contents: []rss.MediaContent{{URL: "video url", Type: "video/mp4", Medium: "video", Width: 1280, Height: 720}},
+						Group: rss.MediaGroup{
+							Thumbnails: []rss.MediaThumbnail{{URL: "small", Width: 120, Height: 68}},
+							Contents:   []rss.MediaContent{{URL: "large", Medium: "image", Width: 1024, Height: 576}},
+						},
+					}},
+				},
+			},
+			expectedResult: &news.Feed{
+				Items: []news.Item{
+					{
+						Category:  "category",
+						Provider:  news.ProviderSky,
+						Title:     title,
+						Link:      link,
+						Thumbnail: "small",
+						DateTime:  now,
+						GUID:      "guid",
+						Authors:   []string{"author 1", "author 2"},
+						Tags:      []string{"politics", "uk"},
+						Enclosures: []news.Enclosure{
+							{URL: "audio url", Type: "audio/mpeg", Length: 2048},
+							{URL: imageURL, Type: "image/jpeg"},
+						},
+						Media: []news.Media{
+							{Kind: news.MediaKindThumbnail, URL: "small", Width: 120, Height: 68},
+							{Kind: news.MediaKindContent, URL: "video url", Type: "video/mp4", Medium: "video", Width: 1280, Height: 720},
+							{Kind: news.MediaKindContent, URL: "large", Medium: "image", Width: 1024, Height: 576},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:         "lenient dates",
+			provider:     news.ProviderBBC,
+			expectedPath: "/category/rss.xml",
+			apiResponse: rss.Response{
+				Channel: rss.Channel{
+					LastBuildDate: "invalid",
+					Items: []rss.Item{
+						{Title: "rfc1123z", PubDate: now.Format(time.RFC1123Z)},
+						{Title: "invalid", PubDate: "yesterday"},
+						{Title: "no weekday", PubDate: now.Format("2 Jan 2006 15:04:05 -0700")},
+						{Title: "no date"},
+						{Title: "unknown zone", PubDate: "Sat, 06 Feb 2021 21:47:21 BST"},
+					},
+				},
+			},
+			expectedResult: &news.Feed{
+				Items: []news.Item{
+					{
+						Category: "category",
+						Provider: news.ProviderBBC,
+						Title:    "rfc1123z",
+						DateTime: now,
+					},
+					{
+						Category: "category",
+						Provider: news.ProviderBBC,
+						Title:    "no weekday",
+						DateTime: now,
+					},
+				},
+				SkippedItems: 3,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.expectedPath, r.URL.Path)
+				w.WriteHeader(http.StatusOK)
+				require.NoError(t, xml.NewEncoder(w).Encode(tc.apiResponse))
+			}))
+			defer s.Close()
+
+			adapter, err := rss.New(tc.provider, s.URL, s.Client(), tc.opts...)
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			res, err := adapter.GetFeed(context.Background(), "category")
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_ThumbnailWidth(t *testing.T) {
+	thumbnails := []rss.MediaThumbnail{
+		{URL: "unsized"},
+		{URL: "small", Width: 120},
+		{URL: "large", Width: 480},
+		{URL: "medium", Width: 240},
+	}
+
+	testCases := []struct {
+		name              string
+		width             int
+		thumbnails        []rss.MediaThumbnail
+		contents          []rss.MediaContent
+		expectedThumbnail string
+	}{
+		{
+			name:              "no width uses first thumbnail",
+			thumbnails:        thumbnails,
+			expectedThumbnail: "unsized",
+		},
+		{
+			name:              "exact width",
+			width:             240,
+			thumbnails:        thumbnails,
+			expectedThumbnail: "medium",
+		},
+		{
+			name:              "narrowest thumbnail wider than width",
+			width:             200,
+			thumbnails:        thumbnails,
+			expectedThumbnail: "medium",
+		},
+		{
+			name:              "widest thumbnail when none are wide enough",
+			width:             1024,
+			thumbnails:        thumbnails,
+			expectedThumbnail: "large",
+		},
+		{
+			name:              "unsized thumbnail when none have a width",
+			width:             240,
+			thumbnails:        []rss.MediaThumbnail{{URL: "unsized"}},
+			expectedThumbnail: "unsized",
+		},
+		{
+			name:  "media content images",
+			width: 240,
+			contents: []rss.MediaContent{
+				{URL: "video", Medium: "video", Width: 240},
+				{URL: "small", Type: "image/jpeg", Width: 120},
+				{URL: "large", Medium: "image", Width: 480},
+			},
+			expectedThumbnail: "large",
+		},
+		{
+			name:              "channel image when item has no images",
+			width:             240,
+			expectedThumbnail: "channel image",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, xml.NewEncoder(w).Encode(rss.Response{
+					Channel: rss.Channel{
+						Image: rss.Image{URL: "channel image"},
+						Items: []rss.Item{{
+							Title:      "title",
+							PubDate:    "Sat, 06 Feb 2021 20:47:21 GMT",
+							Thumbnails: tc.thumbnails,
+							Contents:   tc.contents,
+						}},
+					},
+				}))
+			}))
+			defer s.Close()
+
+			adapter, err := rss.New(news.ProviderBBC, s.URL, s.Client(),
+				rss.WithThumbnail(rss.ThumbnailMediaThumbnail, rss.ThumbnailMediaContent, rss.ThumbnailChannelImage),
+				rss.WithThumbnailWidth(tc.width),
+			)
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			res, err := adapter.GetFeed(context.Background(), "category")
+			require.NoError(t, err)
+			require.Len(t, res.Items, 1)
+
+			assert.Equal(t, tc.expectedThumbnail, res.Items[0].Thumbnail)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_EnclosureThumbnail(t *testing.T) {
+	testCases := []struct {
+		name              string
+		enclosures        []rss.Enclosure
+		expectedThumbnail string
+	}{
+		{
+			name:              "image type",
+			enclosures:        []rss.Enclosure{{URL: "http://test.com/image", Type: "image/jpeg"}},
+			expectedThumbnail: "http://test.com/image",
+		},
+		{
+			name:              "image extension without type",
+			enclosures:        []rss.Enclosure{{URL: "http://test.com/image.JPG?width=240"}},
+			expectedThumbnail: "http://test.com/image.JPG?width=240",
+		},
+		{
+			name: "skips other types",
+			enclosures: []rss.Enclosure{
+				{URL: "http://test.com/audio.jpg", Type: "audio/mpeg"},
+				{URL: "http://test.com/image.png"},
+			},
+			expectedThumbnail: "http://test.com/image.png",
+		},
+		{
+			name:              "no type or image extension",
+			enclosures:        []rss.Enclosure{{URL: "http://test.com/episode.mp3"}, {URL: "http://test.com/download"}},
+			expectedThumbnail: "channel image",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, xml.NewEncoder(w).Encode(rss.Response{
+					Channel: rss.Channel{
+						Image: rss.Image{URL: "channel image"},
+						Items: []rss.Item{{
+							Title:      "title",
+							PubDate:    "Sat, 06 Feb 2021 20:47:21 GMT",
+							Enclosures: tc.enclosures,
+						}},
+					},
+				}))
+			}))
+			defer s.Close()
+
+			adapter, err := rss.New(news.ProviderBBC, s.URL, s.Client(),
+				rss.WithThumbnail(rss.ThumbnailEnclosure, rss.ThumbnailChannelImage),
+			)
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			res, err := adapter.GetFeed(context.Background(), "category")
+			require.NoError(t, err)
+			require.Len(t, res.Items, 1)
+
+			assert.Equal(t, tc.expectedThumbnail, res.Items[0].Thumbnail)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_Atom(t *testing.T) {
+	const (
+		title       = "title"
+		description = "description"
+		link        = "link"
+		imageURL    = "image url"
+		language    = "language"
+		copyright   = "copyright"
+	)
+
+	now := time.Now().UTC().Round(time.Second)
+
+	testCases := []struct {
+		name           string
+		apiResponse    rss.AtomFeed
+		expectedResult *news.Feed
+	}{
+		{
+			name: "atom feed",
+			apiResponse: rss.AtomFeed{
+				Lang:     language,
+				Title:    rss.AtomText{Text: title},
+				Subtitle: rss.AtomText{Text: description},
+				Links: []rss.AtomLink{
+					{Href: "self", Rel: "self"},
+					{Href: link, Rel: "alternate", Type: "text/html"},
+				},
+				Updated: now.Format(time.RFC3339),
+				Rights:  rss.AtomText{Text: copyright},
+				Logo:    "logo",
+				Entries: []rss.AtomEntry{
+					{
+						ID:    "id",
+						Title: rss.AtomText{Text: title},
+						Links: []rss.AtomLink{
+							{Href: link},
+							{Href: "audio url", Rel: "enclosure", Type: "audio/mpeg", Length: 2048},
+						},
+						Updated:    now.Add(time.Hour).Format(time.RFC3339),
+						Published:  now.Format(time.RFC3339),
+						Summary:    rss.AtomText{Text: description},
+						Authors:    []rss.AtomPerson{{Name: "author", Email: "author@test.com"}, {Email: "no name"}},
+						Categories: []rss.AtomCategory{{Term: "politics", Label: "Politics"}},
+						Thumbnails: []rss.MediaThumbnail{{URL: imageURL, Width: 120, Height: 68}},
+					},
+					{
+						Title:   rss.AtomText{Text: title},
+						Links:   []rss.AtomLink{{Href: link, Rel: "alternate"}},
+						Updated: now.Format(time.RFC3339),
+						Content: rss.AtomText{Text: description, Type: "html"},
+					},
+				},
+			},
+			expectedResult: &news.Feed{
+				Title:       title,
+				Description: description,
+				Link:        link,
+				Language:    language,
+				Copyright:   copyright,
+				DateTime:    now,
+				Items: []news.Item{
+					{
+						Category:    "category",
+						Provider:    news.ProviderBBC,
+						Title:       title,
+						Link:        link,
+						Description: description,
+						Thumbnail:   imageURL,
+						DateTime:    now,
+						GUID:        "id",
+						Authors:     []string{"author"},
+						Tags:        []string{"politics"},
+						Enclosures:  []news.Enclosure{{URL: "audio url", Type: "audio/mpeg", Length: 2048}},
+						Media: []news.Media{
+							{Kind: news.MediaKindThumbnail, URL: imageURL, Width: 120, Height: 68},
+						},
+					},
+					{
+						Category:    "category",
+						Provider:    news.ProviderBBC,
+						Title:       title,
+						Link:        link,
+						Description: description,
+						Thumbnail:   "logo",
+						DateTime:    now,
+						GUID:        link,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				require.NoError(t, xml.NewEncoder(w).Encode(tc.apiResponse))
+			}))
+			defer s.Close()
+
+			adapter, err := rss.New(news.ProviderBBC, s.URL, s.Client())
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			res, err := adapter.GetFeed(context.Background(), "category")
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_Charset(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		expectedTitle string
+	}{
+		{
+			name:          "utf-8",
+			body:          `<?xml version="1.0" encoding="UTF-8"?><rss><channel><item><title>café</title><pubDate>Sat, 06 Feb 2021 20:47:21 GMT</pubDate></item></channel></rss>`,
+			expectedTitle: "café",
+		},
+		{
+			name:          "iso-8859-1",
+			body:          "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><rss><channel><item><title>caf\xe9</title><pubDate>Sat, 06 Feb 2021 20:47:21 GMT</pubDate></item></channel></rss>",
+			expectedTitle: "café",
+		},
+		{
+			name:          "windows-1252",
+			body:          "<?xml version=\"1.0\" encoding=\"windows-1252\"?><rss><channel><item><title>\x93caf\xe9\x94</title><pubDate>Sat, 06 Feb 2021 20:47:21 GMT</pubDate></item></channel></rss>",
+			expectedTitle: "“café”",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer s.Close()
+
+			adapter, err := rss.New(news.ProviderBBC, s.URL, s.Client())
+			require.NoError(t, err)
+			require.NotNil(t, adapter)
+
+			res, err := adapter.GetFeed(context.Background(), "category")
+			require.NoError(t, err)
+			require.Len(t, res.Items, 1)
+
+			assert.Equal(t, tc.expectedTitle, res.Items[0].Title)
+		})
+	}
+}
+
+func TestAdapter_GetFeed_MaxItems(t *testing.T) {
+	const maxItems = 10
+
+	testCases := []struct {
+		name  string
+		start string
+		item  string
+		empty string
+		end   string
+	}{
+		{
+			name:  "rss",
+			start: "<rss><channel><title>title</title>",
+			item:  "<item><title>%d</title><pubDate>Sat, 06 Feb 2021 20:47:21 GMT</pubDate></item>",
+			empty: "<item/>",
+			end:   "</channel></rss>",
+		},
+		{
+			name:  "atom",
+			start: `<feed xmlns="http://www.w3.org/2005/Atom"><title>title</title>`,
+			item:  "<entry><id>%d</id><title>%d</title><updated>2021-02-06T20:47:21Z</updated></entry>",
+			empty: "<entry/>",
+			end:   "</feed>",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// a document of about 9MB, which mostly holds empty items after the max
+			var b strings.Builder
+			b.WriteString(tc.start)
+			for i := 0; i < maxItems; i++ {
+				b.WriteString(strings.ReplaceAll(tc.item, "%d", fmt.Sprint(i)))
+			}
+			for b.Len() < 9<<20 {
+				b.WriteString(tc.empty)
+			}
+			b.WriteString(tc.end)
+			body := []byte(b.String())
+
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(body)
+			}))
+			defer s.Close()
+
+			adapter, err := rss.New(news.ProviderBBC, s.URL, s.Client(), rss.WithMaxItems(maxItems))
+			require.NoError(t, err)
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+
+			res, err := adapter.GetFeed(context.Background(), "category")
+			require.NoError(t, err)
+
+			runtime.ReadMemStats(&after)
+
+			assert.Len(t, res.Items, maxItems)
+			assert.Equal(t, "title", res.Title)
+			assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+		})
+	}
+}
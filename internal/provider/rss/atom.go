@@ -0,0 +1,166 @@
+package rss
+
+import (
+	"context"
+	"encoding/xml"
+
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/provider/datetime"
+)
+
+const (
+	linkRelAlternate = "alternate"
+	linkRelEnclosure = "enclosure"
+)
+
+type (
+	AtomFeed struct {
+		XMLName  xml.Name    `xml:"feed"`
+		Lang     string      `xml:"lang,attr"`
+		ID       string      `xml:"id"`
+		Title    AtomText    `xml:"title"`
+		Subtitle AtomText    `xml:"subtitle"`
+		Links    []AtomLink  `xml:"link"`
+		Updated  string      `xml:"updated"`
+		Rights   AtomText    `xml:"rights"`
+		Logo     string      `xml:"logo"`
+		Icon     string      `xml:"icon"`
+		Entries  []AtomEntry `xml:"entry"`
+	}
+
+	AtomEntry struct {
+		ID           string           `xml:"id"`
+		Title        AtomText         `xml:"title"`
+		Links        []AtomLink       `xml:"link"`
+		Updated      string           `xml:"updated"`
+		Published    string           `xml:"published"`
+		Summary      AtomText         `xml:"summary"`
+		Content      AtomText         `xml:"http://www.w3.org/2005/Atom content"`
+		Authors      []AtomPerson     `xml:"author"`
+		Categories   []AtomCategory   `xml:"category"`
+		Thumbnails   []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+		MediaContent []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+		Group        MediaGroup       `xml:"http://search.yahoo.com/mrss/ group"`
+	}
+
+	AtomPerson struct {
+		Name  string `xml:"name"`
+		Email string `xml:"email,omitempty"`
+		URI   string `xml:"uri,omitempty"`
+	}
+
+	AtomCategory struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr,omitempty"`
+	}
+
+	AtomText struct {
+		Text string `xml:",chardata"`
+		Type string `xml:"type,attr,omitempty"`
+	}
+
+	AtomLink struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr,omitempty"`
+		Type   string `xml:"type,attr,omitempty"`
+		Length int64  `xml:"length,attr,omitempty"`
+	}
+)
+
+func (f *AtomFeed) toFeed(ctx context.Context, provider news.Provider, category news.Category, thumbnail []Thumbnail, width int) *news.Feed {
+	var (
+		items   []news.Item
+		skipped int
+	)
+	for _, e := range f.Entries {
+		description := e.Summary.Text
+		if description == "" {
+			description = e.Content.Text
+		}
+
+		date := e.Published
+		if date == "" {
+			date = e.Updated
+		}
+
+		dateTime, err := datetime.Parse(date)
+		if err != nil {
+			logSkippedItem(ctx, provider, category, e.Title.Text, err)
+			skipped++
+			continue
+		}
+
+		link := alternateLink(e.Links)
+
+		var authors []string
+		for _, a := range e.Authors {
+			if a.Name != "" {
+				authors = append(authors, a.Name)
+			}
+		}
+
+		var tags []string
+		for _, c := range e.Categories {
+			if c.Term != "" {
+				tags = append(tags, c.Term)
+			}
+		}
+
+		var enclosures []news.Enclosure
+		for _, l := range e.Links {
+			if l.Rel == linkRelEnclosure && l.Href != "" {
+				enclosures = append(enclosures, news.Enclosure{URL: l.Href, Type: l.Type, Length: l.Length})
+			}
+		}
+
+		media := newItemMedia(e.Thumbnails, e.MediaContent, e.Group, enclosures, f.Logo, f.Icon)
+
+		items = append(items, news.Item{
+			Category:    category,
+			Provider:    provider,
+			Title:       e.Title.Text,
+			Link:        link,
+			Description: description,
+			Thumbnail:   media.thumbnail(thumbnail, width),
+			DateTime:    dateTime,
+			GUID:        firstOf(e.ID, link),
+			Authors:     authors,
+			Tags:        tags,
+			Enclosures:  enclosures,
+			Media:       media.toMedia(),
+		})
+	}
+
+	// the feed date is informational, so an invalid date is ignored
+	updated, _ := datetime.Parse(f.Updated)
+
+	return &news.Feed{
+		Title:        f.Title.Text,
+		Description:  f.Subtitle.Text,
+		Link:         alternateLink(f.Links),
+		Language:     f.Lang,
+		Copyright:    f.Rights.Text,
+		DateTime:     updated,
+		Items:        items,
+		SkippedItems: skipped,
+	}
+}
+
+// alternateLink returns the href of the alternate link, preferring HTML
+// representations. A link without a rel attribute is an alternate link.
+func alternateLink(links []AtomLink) string {
+	var href string
+	for _, l := range links {
+		if l.Rel != "" && l.Rel != linkRelAlternate {
+			continue
+		}
+		if l.Type == "" || l.Type == "text/html" {
+			return l.Href
+		}
+		if href == "" {
+			href = l.Href
+		}
+	}
+
+	return href
+}
@@ -0,0 +1,36 @@
+package rss
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// charsets maps the labels of the legacy encodings still used by some feeds to
+// their decoders. UTF-8 documents are handled by the xml package itself.
+var charsets = map[string]encoding.Encoding{
+	"iso-8859-1":   charmap.ISO8859_1,
+	"iso8859-1":    charmap.ISO8859_1,
+	"iso_8859-1":   charmap.ISO8859_1,
+	"latin1":       charmap.ISO8859_1,
+	"l1":           charmap.ISO8859_1,
+	"iso-8859-15":  charmap.ISO8859_15,
+	"latin9":       charmap.ISO8859_15,
+	"windows-1252": charmap.Windows1252,
+	"cp1252":       charmap.Windows1252,
+	"us-ascii":     encoding.Nop,
+	"ascii":        encoding.Nop,
+}
+
+// charsetReader converts a document declared in a non UTF-8 charset to UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	e, ok := charsets[strings.ToLower(strings.TrimSpace(charset))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported charset: %s", charset)
+	}
+
+	return e.NewDecoder().Reader(input), nil
+}
@@ -0,0 +1,415 @@
+package rss
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cshep4/news-api/internal/log"
+	"github.com/cshep4/news-api/internal/news"
+	"github.com/cshep4/news-api/internal/provider/datetime"
+)
+
+const (
+	ThumbnailMediaThumbnail Thumbnail = "media_thumbnail"
+	ThumbnailMediaContent   Thumbnail = "media_content"
+	ThumbnailEnclosure      Thumbnail = "enclosure"
+	ThumbnailChannelImage   Thumbnail = "channel_image"
+)
+
+var defaultThumbnail = []Thumbnail{
+	ThumbnailMediaThumbnail,
+	ThumbnailMediaContent,
+	ThumbnailEnclosure,
+	ThumbnailChannelImage,
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// imageExtensions are the extensions of the URLs of images that don't have a type.
+var imageExtensions = map[string]struct{}{
+	".avif": {},
+	".gif":  {},
+	".jpeg": {},
+	".jpg":  {},
+	".png":  {},
+	".svg":  {},
+	".webp": {},
+}
+
+type (
+	// Thumbnail identifies a field of the feed that can be mapped to an item thumbnail.
+	Thumbnail string
+
+	Response struct {
+		XMLName xml.Name `xml:"rss"`
+		Text    string   `xml:",chardata"`
+		Dc      string   `xml:"dc,attr"`
+		Content string   `xml:"content,attr"`
+		Atom    string   `xml:"atom,attr"`
+		Version string   `xml:"version,attr"`
+		Media   string   `xml:"media,attr"`
+		Channel Channel  `xml:"channel"`
+	}
+
+	Channel struct {
+		Text          string    `xml:",chardata"`
+		Title         string    `xml:"title"`
+		Description   string    `xml:"description"`
+		Link          string    `xml:"link"`
+		Image         Image     `xml:"image"`
+		Generator     string    `xml:"generator"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Copyright     string    `xml:"copyright"`
+		Language      string    `xml:"language"`
+		Category      string    `xml:"category"`
+		TTL           int       `xml:"ttl"`
+		SkipHours     SkipHours `xml:"skipHours"`
+		SkipDays      SkipDays  `xml:"skipDays"`
+		Items         []Item    `xml:"item"`
+	}
+
+	SkipHours struct {
+		Hours []int `xml:"hour"`
+	}
+
+	SkipDays struct {
+		Days []string `xml:"day"`
+	}
+
+	Image struct {
+		Text  string `xml:",chardata"`
+		URL   string `xml:"url"`
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+	}
+
+	Item struct {
+		Text        string           `xml:",chardata"`
+		Title       string           `xml:"title"`
+		Description string           `xml:"description"`
+		Link        string           `xml:"link"`
+		Guid        Guid             `xml:"guid"`
+		PubDate     string           `xml:"pubDate"`
+		Author      string           `xml:"author"`
+		Creators    []string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Categories  []string         `xml:"category"`
+		Enclosures  []Enclosure      `xml:"enclosure"`
+		Thumbnails  []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+		Contents    []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+		Group       MediaGroup       `xml:"http://search.yahoo.com/mrss/ group"`
+	}
+
+	Guid struct {
+		Text        string `xml:",chardata"`
+		IsPermaLink string `xml:"isPermaLink,attr"`
+	}
+
+	Enclosure struct {
+		Text   string `xml:",chardata"`
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	}
+
+	MediaThumbnail struct {
+		Text   string `xml:",chardata"`
+		URL    string `xml:"url,attr"`
+		Width  int    `xml:"width,attr,omitempty"`
+		Height int    `xml:"height,attr,omitempty"`
+	}
+
+	MediaContent struct {
+		Text   string `xml:",chardata"`
+		Type   string `xml:"type,attr,omitempty"`
+		Medium string `xml:"medium,attr,omitempty"`
+		URL    string `xml:"url,attr"`
+		Width  int    `xml:"width,attr,omitempty"`
+		Height int    `xml:"height,attr,omitempty"`
+	}
+
+	image struct {
+		url   string
+		width int
+	}
+
+	// itemMedia is the media of an RSS item or Atom entry that its thumbnail is
+	// selected from, with the media:group elements merged in.
+	itemMedia struct {
+		thumbnails []MediaThumbnail
+		contents   []MediaContent
+		enclosures []news.Enclosure
+		// channelImages are the images of the feed, used when the item has none.
+		channelImages []string
+	}
+
+	// MediaGroup groups media:content elements that are representations of the same media.
+	MediaGroup struct {
+		Thumbnails []MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+		Contents   []MediaContent   `xml:"http://search.yahoo.com/mrss/ content"`
+	}
+)
+
+func (t Thumbnail) valid() bool {
+	switch t {
+	case ThumbnailMediaThumbnail,
+		ThumbnailMediaContent,
+		ThumbnailEnclosure,
+		ThumbnailChannelImage:
+		return true
+	}
+	return false
+}
+
+func (r *Response) toFeed(ctx context.Context, provider news.Provider, category news.Category, thumbnail []Thumbnail, width int) *news.Feed {
+	var (
+		items   []news.Item
+		skipped int
+	)
+	for _, i := range r.Channel.Items {
+		dateTime, err := datetime.Parse(i.PubDate)
+		if err != nil {
+			logSkippedItem(ctx, provider, category, i.Title, err)
+			skipped++
+			continue
+		}
+
+		authors := i.Creators
+		if len(authors) == 0 && i.Author != "" {
+			authors = []string{i.Author}
+		}
+
+		var enclosures []news.Enclosure
+		for _, e := range i.Enclosures {
+			if e.URL != "" {
+				enclosures = append(enclosures, news.Enclosure{URL: e.URL, Type: e.Type, Length: e.Length})
+			}
+		}
+
+		media := newItemMedia(i.Thumbnails, i.Contents, i.Group, enclosures, r.Channel.Image.URL)
+
+		items = append(items, news.Item{
+			Category:    category,
+			Provider:    provider,
+			Title:       i.Title,
+			Link:        i.Link,
+			Description: i.Description,
+			Thumbnail:   media.thumbnail(thumbnail, width),
+			DateTime:    dateTime,
+			GUID:        firstOf(i.Guid.Text, i.Link),
+			Authors:     authors,
+			Tags:        i.Categories,
+			Enclosures:  enclosures,
+			Media:       media.toMedia(),
+		})
+	}
+
+	// the feed date is informational, so an invalid date is ignored
+	lastBuildDate, _ := datetime.Parse(r.Channel.LastBuildDate)
+
+	return &news.Feed{
+		Title:        r.Channel.Title,
+		Description:  r.Channel.Description,
+		Link:         r.Channel.Link,
+		Language:     r.Channel.Language,
+		Copyright:    r.Channel.Copyright,
+		DateTime:     lastBuildDate,
+		TTL:          r.Channel.TTL,
+		Items:        items,
+		SkippedItems: skipped,
+		SkipHours:    r.Channel.SkipHours.hours(),
+		SkipDays:     r.Channel.SkipDays.days(),
+	}
+}
+
+// hours returns the valid skip hours. Some feeds use 24 rather than 0 for midnight.
+func (s SkipHours) hours() []int {
+	var hours []int
+	for _, h := range s.Hours {
+		switch {
+		case h == 24:
+			hours = append(hours, 0)
+		case h >= 0 && h < 24:
+			hours = append(hours, h)
+		}
+	}
+	return hours
+}
+
+// days returns the valid skip days, ignoring any that aren't day names.
+func (s SkipDays) days() []time.Weekday {
+	var days []time.Weekday
+	for _, d := range s.Days {
+		if day, ok := weekdays[strings.ToLower(strings.TrimSpace(d))]; ok {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// newItemMedia returns the media of an item, followed by those of its media:group.
+func newItemMedia(thumbnails []MediaThumbnail, contents []MediaContent, group MediaGroup, enclosures []news.Enclosure, channelImages ...string) itemMedia {
+	return itemMedia{
+		thumbnails:    append(append([]MediaThumbnail(nil), thumbnails...), group.Thumbnails...),
+		contents:      append(append([]MediaContent(nil), contents...), group.Contents...),
+		enclosures:    enclosures,
+		channelImages: channelImages,
+	}
+}
+
+// thumbnail returns the URL of the first image found in the fields of thumbnail, in
+// order, picking the one that best fits width where there are several.
+func (m itemMedia) thumbnail(thumbnail []Thumbnail, width int) string {
+	for _, t := range thumbnail {
+		switch t {
+		case ThumbnailMediaThumbnail:
+			if url := bestThumbnail(m.thumbnails, width); url != "" {
+				return url
+			}
+		case ThumbnailMediaContent:
+			if url := bestContent(m.contents, width); url != "" {
+				return url
+			}
+		case ThumbnailEnclosure:
+			for _, e := range m.enclosures {
+				if e.URL != "" && isImage(e.Type, e.URL) {
+					return e.URL
+				}
+			}
+		case ThumbnailChannelImage:
+			for _, url := range m.channelImages {
+				if url != "" {
+					return url
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+func (m MediaContent) isImage() bool {
+	if m.Medium != "" {
+		return m.Medium == "image"
+	}
+	return isImage(m.Type, m.URL)
+}
+
+// bestThumbnail returns the URL of the thumbnail that best fits width.
+func bestThumbnail(thumbnails []MediaThumbnail, width int) string {
+	images := make([]image, 0, len(thumbnails))
+	for _, t := range thumbnails {
+		images = append(images, image{url: t.URL, width: t.Width})
+	}
+	return bestImage(images, width)
+}
+
+// bestContent returns the URL of the image media:content that best fits width.
+func bestContent(contents []MediaContent, width int) string {
+	images := make([]image, 0, len(contents))
+	for _, c := range contents {
+		if c.isImage() {
+			images = append(images, image{url: c.URL, width: c.Width})
+		}
+	}
+	return bestImage(images, width)
+}
+
+// bestImage returns the URL of the narrowest image at least width wide, or the
+// widest image if none are wide enough. Images without a width are only used when
+// no image has one, and the first image is used when width is 0.
+func bestImage(images []image, width int) string {
+	var best image
+	for _, i := range images {
+		switch {
+		case i.url == "":
+			continue
+		case width == 0:
+			return i.url
+		case best.url == "":
+			best = i
+		case best.width < width && i.width > best.width:
+			// the best image is too narrow, so prefer anything wider
+			best = i
+		case i.width >= width && i.width < best.width:
+			best = i
+		}
+	}
+
+	return best.url
+}
+
+func (m itemMedia) toMedia() []news.Media {
+	var media []news.Media
+	for _, t := range m.thumbnails {
+		if t.URL == "" {
+			continue
+		}
+		media = append(media, news.Media{
+			Kind:   news.MediaKindThumbnail,
+			URL:    t.URL,
+			Width:  t.Width,
+			Height: t.Height,
+		})
+	}
+	for _, c := range m.contents {
+		if c.URL == "" {
+			continue
+		}
+		media = append(media, news.Media{
+			Kind:   news.MediaKindContent,
+			URL:    c.URL,
+			Type:   c.Type,
+			Medium: c.Medium,
+			Width:  c.Width,
+			Height: c.Height,
+		})
+	}
+
+	return media
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func logSkippedItem(ctx context.Context, provider news.Provider, category news.Category, title string, err error) {
+	log.Info(ctx, "feed_item_skipped",
+		log.SafeParam("provider", provider),
+		log.SafeParam("category", category),
+		log.SafeParam("title", title),
+		log.ErrorParam(err),
+	)
+}
+
+// isImage reports whether the media at rawURL with mimeType is an image, going by its
+// extension when it has no type.
+func isImage(mimeType, rawURL string) bool {
+	if mimeType != "" {
+		return strings.HasPrefix(mimeType, "image/")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	_, ok := imageExtensions[strings.ToLower(path.Ext(u.Path))]
+	return ok
+}
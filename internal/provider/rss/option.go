@@ -0,0 +1,46 @@
+package rss
+
+import "github.com/cshep4/news-api/internal/provider/httpfeed"
+
+type Option func(*adapter)
+
+// WithURLTemplate sets the template used to build the feed URL for a category,
+// e.g. "{base}/{category}.xml". The template must contain "{category}".
+func WithURLTemplate(template string) Option {
+	return func(a *adapter) {
+		a.urlTemplate = template
+	}
+}
+
+// WithThumbnail sets the item fields used for the thumbnail, in order of
+// preference. The first field with a value is used.
+func WithThumbnail(thumbnail ...Thumbnail) Option {
+	return func(a *adapter) {
+		a.thumbnail = thumbnail
+	}
+}
+
+// WithThumbnailWidth sets the preferred width of item thumbnails in pixels. When an
+// item has several media images, the smallest that is at least width wide is used,
+// or the largest if none are wide enough.
+func WithThumbnailWidth(width int) Option {
+	return func(a *adapter) {
+		a.thumbnailWidth = width
+	}
+}
+
+// WithMaxBodySize sets the max size of a feed document in bytes, defaulting to
+// httpfeed.DefaultMaxBodySize.
+func WithMaxBodySize(size int64) Option {
+	return func(a *adapter) {
+		a.fetcherOpts = append(a.fetcherOpts, httpfeed.WithMaxBodySize(size))
+	}
+}
+
+// WithMaxItems sets the max number of items returned for a feed, defaulting to
+// httpfeed.DefaultMaxItems.
+func WithMaxItems(items int) Option {
+	return func(a *adapter) {
+		a.fetcherOpts = append(a.fetcherOpts, httpfeed.WithMaxItems(items))
+	}
+}
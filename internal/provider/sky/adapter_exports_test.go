@@ -1,3 +0,0 @@
-package sky
-
-type ResTime = resTime
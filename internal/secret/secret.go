@@ -6,14 +6,12 @@ import (
 )
 
 type Secrets struct {
-	BBCURL string
-	SkyURL string
+	ConfigPath string
 }
 
 func (s *Secrets) Load() error {
 	for k, v := range map[string]*string{
-		"BBC_URL": &s.BBCURL,
-		"SKY_URL": &s.SkyURL,
+		"CONFIG_PATH": &s.ConfigPath,
 	} {
 		var ok bool
 		if *v, ok = os.LookupEnv(k); !ok {